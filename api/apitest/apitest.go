@@ -0,0 +1,122 @@
+// apitest.go
+package apitest
+
+import (
+	"edsb/api/routes"
+	"edsb/api/session"
+	"edsb/events"
+	"edsb/models"
+	"edsb/store"
+	"edsb/store/memory"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// Server é a aplicação completa rodando num httptest.Server sobre o store em
+// memória, para testar os handlers sem Postgres
+type Server struct {
+	*httptest.Server
+	Store *store.Store
+	Hub   *events.Hub
+}
+
+// NewServer sobe o servidor, que é encerrado ao fim do teste
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	st, hub := memory.New(), events.NewHub()
+	r := mux.NewRouter()
+	routes.ConfigureRoutes(r, st, hub)
+	srv := &Server{Server: httptest.NewServer(r), Store: st, Hub: hub}
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// Client faz requisições ao Server, autenticado pelo cookie de sessão (ou
+// anônimo, com Cookie vazio)
+type Client struct {
+	ID     int
+	Cookie string
+	srv    *Server
+}
+
+// Anonymous retorna um cliente sem sessão
+func (srv *Server) Anonymous() *Client {
+	return &Client{srv: srv}
+}
+
+// User cria um usuário com o papel informado (vazio para o padrão) e uma
+// sessão para ele
+func (srv *Server) User(t testing.TB, name, role string) *Client {
+	t.Helper()
+	user := models.User{Username: name, Email: name + "@example.com"}
+	if err := srv.Store.Users.Create(&user, "hash"); err != nil {
+		t.Fatal(err)
+	}
+	if role != "" {
+		user.Role = role
+		if err := srv.Store.Users.Update(&user); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sess, err := session.Create(srv.Store, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Client{ID: user.ID, Cookie: session.CookieName + "=" + sess.ID, srv: srv}
+}
+
+// Do envia body (JSON, se não vazio) e decodifica a resposta JSON em out, se
+// não for nil. Retorna o status da resposta.
+func (c *Client) Do(t testing.TB, method, path, body string, out any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, c.srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Cookie != "" {
+		req.Header.Set("Cookie", c.Cookie)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != nil && res.StatusCode < 300 {
+		if err := json.Unmarshal(data, out); err != nil {
+			t.Fatalf("%s %s: resposta inválida %q: %v", method, path, data, err)
+		}
+	}
+	return res.StatusCode
+}
+
+// Form envia um formulário, como as páginas de login e cadastro. Retorna o
+// status da resposta.
+func (c *Client) Form(t testing.TB, path string, values url.Values) int {
+	t.Helper()
+	req, err := http.NewRequest("POST", c.srv.URL+path, strings.NewReader(values.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	return res.StatusCode
+}
@@ -0,0 +1,89 @@
+// auth.go
+package auth
+
+import (
+	"context"
+	"edsb/api/session"
+	"edsb/models"
+	"edsb/store"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// contextKey evita colisões com chaves de contexto de outros pacotes
+type contextKey struct{}
+
+var userKey = contextKey{}
+
+// Middleware autentica o usuário a partir do cookie de sessão e o injeta no
+// contexto da requisição. Requisições sem sessão seguem como anônimas.
+func Middleware(s *store.Store) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, err := session.CurrentUser(s, w, r)
+			if err != nil {
+				if !errors.Is(err, session.ErrNoSession) {
+					log.Printf("Erro ao resolver sessão: %v", err)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), user)))
+		})
+	}
+}
+
+// RequireUser bloqueia com 401 as requisições que não estão autenticadas
+func RequireUser(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if UserFromContext(r.Context()) == nil {
+			w.Header().Set("Content-Type", "application/json")
+			http.Error(w, `{"error": "Autenticação necessária"}`, http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// WithUser retorna uma cópia do contexto carregando o usuário autenticado
+func WithUser(ctx context.Context, user *models.User) context.Context {
+	return context.WithValue(ctx, userKey, user)
+}
+
+// UserFromContext retorna o usuário autenticado, ou nil se a requisição é anônima
+func UserFromContext(ctx context.Context) *models.User {
+	user, _ := ctx.Value(userKey).(*models.User)
+	return user
+}
+
+// HasRole indica se o usuário possui algum dos papéis informados
+func HasRole(user *models.User, roles ...string) bool {
+	if user == nil {
+		return false
+	}
+	for _, role := range roles {
+		if user.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// CanModify indica se o usuário pode alterar um recurso pertencente a ownerID:
+// o próprio dono sempre pode, os demais apenas se tiverem um papel privilegiado
+func CanModify(user *models.User, ownerID int, privileged ...string) bool {
+	if user == nil {
+		return false
+	}
+	return user.ID == ownerID || HasRole(user, privileged...)
+}
+
+// Forbidden responde 403 com um erro em JSON
+func Forbidden(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	http.Error(w, `{"error": "Você não tem permissão para alterar este recurso"}`, http.StatusForbidden)
+}
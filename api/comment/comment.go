@@ -2,123 +2,252 @@
 package comment
 
 import (
-	"database/sql"
+	"edsb/api/auth"
+	"edsb/api/mention"
+	"edsb/api/notification"
+	"edsb/api/pagination"
+	"edsb/events"
 	"edsb/models"
+	"edsb/store"
+	"edsb/views"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 )
 
-// Cria a tabela de comentários
-func CreateCommentsTable(db *sql.DB) error {
-	query := `
-	CREATE TABLE IF NOT EXISTS comments (
-		id SERIAL PRIMARY KEY,
-		post_id INT REFERENCES posts(id) ON DELETE CASCADE,
-		user_id INT REFERENCES users(id) ON DELETE CASCADE,
-		content TEXT NOT NULL,
-		likes_count INT DEFAULT 0,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);`
-
-	if _, err := db.Exec(query); err != nil {
-		return err
-	}
-	log.Println("Tabela comments criada com sucesso (se não existia).")
-	return nil
-}
+// MaxDepth é a profundidade máxima de respostas aninhadas (0 = comentário de
+// primeiro nível). Configurável pela variável de ambiente COMMENT_MAX_DEPTH.
+var MaxDepth = 5
 
 // Handler para obter todos os comentários
-func GetComments(db *sql.DB) http.HandlerFunc {
+func GetComments(s *store.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		rows, err := db.Query("SELECT id, post_id, user_id, content, created_at FROM comments")
+		page, ok := pagination.FromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		comments, next, err := s.Comments.List(page)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		defer rows.Close()
 
-		var comments []models.Comment
-		for rows.Next() {
-			var comment models.Comment
-			if err := rows.Scan(&comment.ID, &comment.PostID, &comment.UserID, &comment.Content, &comment.CreatedAt); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+		if views.IsHTMX(r) {
+			views.RenderPartial(w, http.StatusOK, "comment-list", views.CommentList{Comments: comments, NextURL: views.NextURL(r, next)})
+			return
+		}
+		pagination.Write(w, comments, next)
+	}
+}
+
+// Handler para obter os comentários de um post com suas respostas. A página
+// (?limit=, ?cursor=) é de comentários de primeiro nível; ?mode=tree (padrão)
+// aninha as respostas em "replies" e ?mode=flat as lista em ordem de leitura,
+// cada uma com seu "depth".
+func GetPostComments(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		postID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, `{"error": "Post não encontrado"}`, http.StatusNotFound)
+			return
+		}
+
+		mode := r.URL.Query().Get("mode")
+		if mode == "" {
+			mode = "tree"
+		}
+		if mode != "tree" && mode != "flat" {
+			http.Error(w, `{"error": "mode deve ser tree ou flat"}`, http.StatusBadRequest)
+			return
+		}
+
+		page, ok := pagination.FromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		if _, err := s.Posts.Get(postID); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, `{"error": "Post não encontrado"}`, http.StatusNotFound)
 				return
 			}
-			comments = append(comments, comment)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(comments)
+		roots, next, err := s.Comments.ListByPost(postID, page)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		rootIDs := make([]int, len(roots))
+		for i, root := range roots {
+			rootIDs[i] = root.ID
+		}
+		replies, err := s.Comments.Replies(rootIDs)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		thread := buildThread(roots, replies)
+		if mode == "flat" {
+			thread = flattenThread(thread)
+		}
+		if views.IsHTMX(r) {
+			views.RenderPartial(w, http.StatusOK, "comment-list", views.CommentList{Comments: thread, NextURL: views.NextURL(r, next)})
+			return
+		}
+		pagination.Write(w, thread, next)
 	}
 }
 
 // Handler para obter um comentário específico
-func GetComment(db *sql.DB) http.HandlerFunc {
+func GetComment(s *store.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id := mux.Vars(r)["id"]
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, `{"error": "Comentário não encontrado"}`, http.StatusNotFound)
+			return
+		}
 
-		var comment models.Comment
-		query := "SELECT id, post_id, user_id, content, created_at FROM comments WHERE id = $1"
-		if err := db.QueryRow(query, id).Scan(&comment.ID, &comment.PostID, &comment.UserID, &comment.Content, &comment.CreatedAt); err != nil {
-			http.Error(w, err.Error(), http.StatusNotFound)
+		comment, err := s.Comments.Get(id)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, `{"error": "Comentário não encontrado"}`, http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if comment.Reactions, err = s.Reactions.CountsForComment(comment.ID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
+		if views.IsHTMX(r) {
+			views.RenderPartial(w, http.StatusOK, "comment", comment)
+			return
+		}
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(comment)
 	}
 }
 
 // Handler para criar um novo comentário
-func CreateComment(db *sql.DB) http.HandlerFunc {
+func CreateComment(s *store.Store, hub *events.Hub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		user := auth.UserFromContext(r.Context())
+
 		var comment models.Comment
 		if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		query := "INSERT INTO comments (post_id, user_id, content) VALUES ($1, $2, $3) RETURNING id, created_at"
-		err := db.QueryRow(query, comment.PostID, comment.UserID, comment.Content).Scan(&comment.ID, &comment.CreatedAt)
-		if err != nil {
+		// O autor é sempre o usuário autenticado
+		if comment.UserID != 0 && comment.UserID != user.ID {
+			http.Error(w, `{"error": "Não é permitido comentar em nome de outro usuário"}`, http.StatusForbidden)
+			return
+		}
+		comment.UserID = user.ID
+
+		// Respostas herdam o post do comentário pai e ficam um nível abaixo dele
+		comment.Depth = 0
+		if comment.ParentID != nil {
+			parent, err := s.Comments.Get(*comment.ParentID)
+			if err != nil {
+				if errors.Is(err, store.ErrNotFound) {
+					http.Error(w, `{"error": "Comentário pai não encontrado"}`, http.StatusNotFound)
+					return
+				}
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if comment.PostID != 0 && comment.PostID != parent.PostID {
+				http.Error(w, `{"error": "O comentário pai pertence a outro post"}`, http.StatusBadRequest)
+				return
+			}
+			comment.PostID = parent.PostID
+			comment.Depth = parent.Depth + 1
+			if comment.Depth > MaxDepth {
+				http.Error(w, `{"error": "Limite de respostas aninhadas atingido"}`, http.StatusBadRequest)
+				return
+			}
+		}
+
+		if err := s.Comments.Create(&comment); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, `{"error": "Post não encontrado"}`, http.StatusNotFound)
+				return
+			}
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		notification.Commented(s, hub, comment)
+		mention.SyncComment(s, hub, comment)
+		publishComment(hub, comment)
 
+		if views.IsHTMX(r) {
+			views.RenderPartial(w, http.StatusCreated, "comment", comment)
+			return
+		}
 		w.WriteHeader(http.StatusCreated)
 		json.NewEncoder(w).Encode(comment)
 	}
 }
 
 // Handler para atualizar um comentário existente
-func UpdateComment(db *sql.DB) http.HandlerFunc {
+func UpdateComment(s *store.Store, hub *events.Hub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id := mux.Vars(r)["id"]
+		id, ok := authorizeComment(s, w, r)
+		if !ok {
+			return
+		}
+
 		var comment models.Comment
 		if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		query := "UPDATE comments SET content = $1 WHERE id = $2"
-		if _, err := db.Exec(query, comment.Content, id); err != nil {
+		comment.ID = id
+		if err := s.Comments.Update(&comment, auth.UserFromContext(r.Context()).ID); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
+		// As menções são atribuídas ao autor, mesmo quando um moderador edita
+		updated, err := s.Comments.Get(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		mention.SyncComment(s, hub, *updated)
+
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
 
 // Handler para deletar um comentário
-func DeleteComment(db *sql.DB) http.HandlerFunc {
+func DeleteComment(s *store.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id := mux.Vars(r)["id"]
-		query := "DELETE FROM comments WHERE id = $1"
-		if _, err := db.Exec(query, id); err != nil {
+		id, ok := authorizeComment(s, w, r)
+		if !ok {
+			return
+		}
+
+		if err := s.Comments.Delete(id); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -126,3 +255,122 @@ func DeleteComment(db *sql.DB) http.HandlerFunc {
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+// Handler para restaurar um comentário removido. Somente o autor ou um
+// administrador podem restaurá-lo, e apenas dentro de models.RestoreWindow.
+func RestoreComment(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, `{"error": "Comentário removido não encontrado"}`, http.StatusNotFound)
+			return
+		}
+
+		deleted, err := s.Comments.Deleted(id)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, `{"error": "Comentário removido não encontrado"}`, http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if !auth.CanModify(auth.UserFromContext(r.Context()), deleted.UserID, models.RoleAdmin) {
+			auth.Forbidden(w)
+			return
+		}
+		if !models.Restorable(*deleted.DeletedAt) {
+			http.Error(w, `{"error": "Prazo para restauração expirado"}`, http.StatusGone)
+			return
+		}
+
+		if err := s.Comments.Restore(id); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		comment, err := s.Comments.Get(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(comment)
+	}
+}
+
+// publishComment avisa os clientes conectados em /events do novo comentário:
+// "comments-posts-{id}" para comentários de primeiro nível e
+// "replies-comments-{id}" para respostas
+func publishComment(hub *events.Hub, comment models.Comment) {
+	name := fmt.Sprintf("comments-posts-%d", comment.PostID)
+	if comment.ParentID != nil {
+		name = fmt.Sprintf("replies-comments-%d", *comment.ParentID)
+	}
+	html, err := views.Partial("comment", comment)
+	if err != nil {
+		log.Printf("Erro ao renderizar comentário %d para o stream: %v", comment.ID, err)
+	}
+	hub.Publish(events.Event{Name: name, PostID: comment.PostID, Data: comment, HTML: html})
+}
+
+// authorizeComment verifica se o comentário do path existe e se quem faz a
+// requisição pode alterá-lo (o autor, um moderador ou um administrador). Em
+// caso negativo a resposta de erro já é escrita e ok é false.
+func authorizeComment(s *store.Store, w http.ResponseWriter, r *http.Request) (id int, ok bool) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error": "Comentário não encontrado"}`, http.StatusNotFound)
+		return 0, false
+	}
+
+	comment, err := s.Comments.Get(id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, `{"error": "Comentário não encontrado"}`, http.StatusNotFound)
+			return 0, false
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return 0, false
+	}
+
+	if !auth.CanModify(auth.UserFromContext(r.Context()), comment.UserID, models.RoleModerator, models.RoleAdmin) {
+		auth.Forbidden(w)
+		return 0, false
+	}
+	return id, true
+}
+
+// buildThread aninha as respostas sob seus comentários pais, preservando a
+// ordem das raízes e a ordem cronológica das respostas
+func buildThread(roots, replies []models.Comment) []models.Comment {
+	children := map[int][]models.Comment{}
+	for _, reply := range replies {
+		children[*reply.ParentID] = append(children[*reply.ParentID], reply)
+	}
+
+	var attach func(comments []models.Comment) []models.Comment
+	attach = func(comments []models.Comment) []models.Comment {
+		for i := range comments {
+			comments[i].Replies = attach(children[comments[i].ID])
+		}
+		return comments
+	}
+	return attach(roots)
+}
+
+// flattenThread lista a árvore em ordem de leitura (cada comentário seguido das
+// suas respostas), sem o campo "replies"
+func flattenThread(thread []models.Comment) []models.Comment {
+	flat := make([]models.Comment, 0, len(thread))
+	for _, comment := range thread {
+		replies := comment.Replies
+		comment.Replies = nil
+		flat = append(flat, comment)
+		flat = append(flat, flattenThread(replies)...)
+	}
+	return flat
+}
@@ -0,0 +1,110 @@
+// comment_test.go
+package comment_test
+
+import (
+	"edsb/api/apitest"
+	"edsb/models"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func newComment(t *testing.T, c *apitest.Client, body string) models.Comment {
+	t.Helper()
+	var comment models.Comment
+	if status := c.Do(t, "POST", "/comments", body, &comment); status != http.StatusCreated {
+		t.Fatalf("POST /comments %s = %d", body, status)
+	}
+	return comment
+}
+
+// tree carrega os comentários do post no modo árvore
+func tree(t *testing.T, c *apitest.Client, postID int) []models.Comment {
+	t.Helper()
+	var page struct {
+		Data []models.Comment `json:"data"`
+	}
+	if status := c.Do(t, "GET", fmt.Sprintf("/posts/%d/comments", postID), "", &page); status != http.StatusOK {
+		t.Fatalf("GET comments = %d", status)
+	}
+	return page.Data
+}
+
+func TestDeletedCommentKeepsReplies(t *testing.T) {
+	srv := apitest.NewServer(t)
+	ana, bia := srv.User(t, "ana", ""), srv.User(t, "bia", "")
+
+	var post models.Post
+	ana.Do(t, "POST", "/posts", `{"title": "Título", "content": "Conteúdo"}`, &post)
+	root := newComment(t, ana, fmt.Sprintf(`{"post_id": %d, "content": "raiz"}`, post.ID))
+	leaf := newComment(t, ana, fmt.Sprintf(`{"post_id": %d, "content": "folha"}`, post.ID))
+	reply := newComment(t, bia, fmt.Sprintf(`{"parent_id": %d, "content": "resposta"}`, root.ID))
+
+	for _, id := range []int{root.ID, leaf.ID} {
+		if status := ana.Do(t, "DELETE", fmt.Sprintf("/comments/%d", id), "", nil); status != http.StatusNoContent {
+			t.Fatalf("DELETE comentário %d = %d", id, status)
+		}
+	}
+
+	// O removido sem respostas some; o com respostas fica como [removido]
+	comments := tree(t, ana, post.ID)
+	if len(comments) != 1 {
+		t.Fatalf("comentários = %+v, esperado só a raiz removida", comments)
+	}
+	got := comments[0]
+	if got.ID != root.ID || got.Content != models.RemovedContent || got.UserID != 0 || got.DeletedAt == nil {
+		t.Fatalf("raiz removida = %+v", got)
+	}
+	if len(got.Replies) != 1 || got.Replies[0].ID != reply.ID || got.Replies[0].Content != "resposta" {
+		t.Fatalf("respostas = %+v, esperado a resposta intacta", got.Replies)
+	}
+
+	var p models.Post
+	ana.Do(t, "GET", fmt.Sprintf("/posts/%d", post.ID), "", &p)
+	if p.CommentsCount != 1 {
+		t.Fatalf("comments_count = %d, esperado 1", p.CommentsCount)
+	}
+
+	if status := ana.Do(t, "GET", fmt.Sprintf("/comments/%d", root.ID), "", nil); status != http.StatusNotFound {
+		t.Fatalf("GET comentário removido = %d, esperado 404", status)
+	}
+	body := fmt.Sprintf(`{"parent_id": %d, "content": "outra"}`, root.ID)
+	if status := bia.Do(t, "POST", "/comments", body, nil); status != http.StatusNotFound {
+		t.Fatalf("resposta a comentário removido = %d, esperado 404", status)
+	}
+}
+
+func TestRestoreComment(t *testing.T) {
+	srv := apitest.NewServer(t)
+	ana, bia := srv.User(t, "ana", ""), srv.User(t, "bia", "")
+
+	var post models.Post
+	ana.Do(t, "POST", "/posts", `{"title": "Título", "content": "Conteúdo"}`, &post)
+	comment := newComment(t, ana, fmt.Sprintf(`{"post_id": %d, "content": "original"}`, post.ID))
+	path := fmt.Sprintf("/comments/%d", comment.ID)
+	ana.Do(t, "DELETE", path, "", nil)
+
+	if status := bia.Do(t, "POST", path+"/restore", "", nil); status != http.StatusForbidden {
+		t.Fatalf("restore por outro usuário = %d, esperado 403", status)
+	}
+
+	window := models.RestoreWindow
+	t.Cleanup(func() { models.RestoreWindow = window })
+	models.RestoreWindow = 0
+	if status := ana.Do(t, "POST", path+"/restore", "", nil); status != http.StatusGone {
+		t.Fatalf("restore fora do prazo = %d, esperado 410", status)
+	}
+	models.RestoreWindow = time.Hour
+
+	var restored models.Comment
+	if status := ana.Do(t, "POST", path+"/restore", "", &restored); status != http.StatusOK {
+		t.Fatalf("restore = %d, esperado 200", status)
+	}
+	if restored.Content != "original" || restored.UserID != ana.ID {
+		t.Fatalf("comentário restaurado = %+v", restored)
+	}
+	if comments := tree(t, ana, post.ID); len(comments) != 1 || comments[0].Content != "original" {
+		t.Fatalf("comentários = %+v", comments)
+	}
+}
@@ -0,0 +1,327 @@
+// conversation.go
+package conversation
+
+import (
+	"edsb/api/auth"
+	"edsb/api/pagination"
+	"edsb/events"
+	"edsb/models"
+	"edsb/store"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// MaxMembers é o número máximo de participantes de uma conversa em grupo,
+// contando quem a criou
+var MaxMembers = 10
+
+// Handler para iniciar uma conversa com um ou mais usuários. Com um único
+// usuário a conversa é direta e, se já existir, ela é retornada com 200.
+func StartConversation(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			UserIDs []int  `json:"user_ids"`
+			Title   string `json:"title"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error": "Corpo da requisição inválido"}`, http.StatusBadRequest)
+			return
+		}
+
+		user := auth.UserFromContext(r.Context())
+		others := otherMembers(user.ID, req.UserIDs)
+		if len(others) == 0 {
+			http.Error(w, `{"error": "Informe com quem deseja conversar"}`, http.StatusBadRequest)
+			return
+		}
+		if len(others)+1 > MaxMembers {
+			http.Error(w, `{"error": "Limite de participantes atingido"}`, http.StatusBadRequest)
+			return
+		}
+
+		conv := models.Conversation{CreatedBy: user.ID, IsGroup: len(others) > 1}
+		if conv.IsGroup {
+			conv.Title = strings.TrimSpace(req.Title)
+		} else {
+			existing, err := s.Conversations.FindDirect(user.ID, others[0])
+			if err == nil {
+				json.NewEncoder(w).Encode(existing)
+				return
+			}
+			if !errors.Is(err, store.ErrNotFound) {
+				http.Error(w, `{"error": "Erro ao iniciar conversa"}`, http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if err := s.Conversations.Create(&conv, append([]int{user.ID}, others...)); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, `{"error": "Usuário não encontrado"}`, http.StatusNotFound)
+				return
+			}
+			http.Error(w, `{"error": "Erro ao iniciar conversa"}`, http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(conv)
+	}
+}
+
+// Handler para listar as conversas do usuário autenticado, da que recebeu
+// mensagem mais recentemente para a mais antiga
+func GetConversations(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		page, ok := pagination.FromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		user := auth.UserFromContext(r.Context())
+		convs, next, err := s.Conversations.List(user.ID, page)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		pagination.Write(w, convs, next)
+	}
+}
+
+// Handler para obter uma conversa com seus participantes e recibos de leitura
+func GetConversation(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, ok := conversationID(w, r)
+		if !ok {
+			return
+		}
+
+		conv, err := s.Conversations.Get(id, auth.UserFromContext(r.Context()).ID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(conv)
+	}
+}
+
+// Handler para listar as mensagens de uma conversa, da mais nova para a mais
+// antiga
+func GetMessages(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, ok := conversationID(w, r)
+		if !ok {
+			return
+		}
+		page, ok := pagination.FromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		messages, next, err := s.Conversations.Messages(id, auth.UserFromContext(r.Context()).ID, page)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		pagination.Write(w, messages, next)
+	}
+}
+
+// Handler para enviar uma mensagem numa conversa. A mensagem também é
+// publicada no Hub para os participantes que assinaram a conversa.
+func SendMessage(s *store.Store, hub *events.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, ok := conversationID(w, r)
+		if !ok {
+			return
+		}
+
+		var msg models.Message
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			http.Error(w, `{"error": "Corpo da requisição inválido"}`, http.StatusBadRequest)
+			return
+		}
+		msg.Content = strings.TrimSpace(msg.Content)
+		if msg.Content == "" {
+			http.Error(w, `{"error": "A mensagem não pode ser vazia"}`, http.StatusBadRequest)
+			return
+		}
+
+		// O remetente é sempre o usuário autenticado
+		msg.ConversationID = id
+		msg.UserID = auth.UserFromContext(r.Context()).ID
+		if err := s.Conversations.Send(&msg); err != nil {
+			writeError(w, err)
+			return
+		}
+		publishMessage(s, hub, msg)
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(msg)
+	}
+}
+
+// Handler para registrar o recibo de leitura até a mensagem informada em
+// {"message_id": ...} ou, sem corpo, até a última mensagem
+func MarkRead(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, ok := conversationID(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			MessageID int `json:"message_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, `{"error": "Corpo da requisição inválido"}`, http.StatusBadRequest)
+			return
+		}
+
+		if err := s.Conversations.MarkRead(id, auth.UserFromContext(r.Context()).ID, req.MessageID); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Handler para silenciar uma conversa para o usuário autenticado: ele deixa de
+// receber os avisos unread-conversations-{id}, mas as mensagens continuam
+// chegando às conexões que assinam a conversa
+func MuteConversation(s *store.Store) http.HandlerFunc {
+	return setMuted(s, true)
+}
+
+// Handler para voltar a receber avisos de uma conversa silenciada
+func UnmuteConversation(s *store.Store) http.HandlerFunc {
+	return setMuted(s, false)
+}
+
+func setMuted(s *store.Store, muted bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, ok := conversationID(w, r)
+		if !ok {
+			return
+		}
+
+		if err := s.Conversations.SetMuted(id, auth.UserFromContext(r.Context()).ID, muted); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Handler para o usuário autenticado sair de uma conversa
+func LeaveConversation(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, ok := conversationID(w, r)
+		if !ok {
+			return
+		}
+
+		if err := s.Conversations.Leave(id, auth.UserFromContext(r.Context()).ID); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Unread é o aviso de mensagem nova enviado a todas as conexões de um
+// participante, assinem elas a conversa ou não
+type Unread struct {
+	ConversationID int `json:"conversation_id"`
+	UnreadCount    int `json:"unread_count"`
+}
+
+// publishMessage publica a mensagem para cada participante ativo da conversa,
+// de modo que quem saiu dela deixa de recebê-las mesmo com a assinatura aberta.
+// Os demais participantes que não silenciaram a conversa recebem também o
+// aviso unread-conversations-{id}.
+func publishMessage(s *store.Store, hub *events.Hub, msg models.Message) {
+	conv, err := s.Conversations.Get(msg.ConversationID, msg.UserID)
+	if err != nil {
+		log.Printf("Erro ao publicar mensagem %d: %v", msg.ID, err)
+		return
+	}
+	name := fmt.Sprintf("messages-conversations-%d", msg.ConversationID)
+	unread := fmt.Sprintf("unread-conversations-%d", msg.ConversationID)
+	for _, m := range conv.Members {
+		if m.LeftAt != nil {
+			continue
+		}
+		hub.Publish(events.Event{Name: name, UserID: m.UserID, ConversationID: msg.ConversationID, Data: msg})
+		if m.UserID == msg.UserID {
+			continue
+		}
+
+		// Muted e UnreadCount são do ponto de vista de cada participante
+		view, err := s.Conversations.Get(msg.ConversationID, m.UserID)
+		if err != nil {
+			log.Printf("Erro ao avisar o usuário %d da mensagem %d: %v", m.UserID, msg.ID, err)
+			continue
+		}
+		if !view.Muted {
+			hub.Publish(events.Event{Name: unread, UserID: m.UserID, Data: Unread{ConversationID: view.ID, UnreadCount: view.UnreadCount}})
+		}
+	}
+}
+
+// conversationID extrai o {id} do path. Em caso de erro a resposta já é
+// escrita e ok é false.
+func conversationID(w http.ResponseWriter, r *http.Request) (id int, ok bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error": "Conversa não encontrada"}`, http.StatusNotFound)
+		return 0, false
+	}
+	return id, true
+}
+
+// writeError responde com 404 para conversas (ou mensagens) inexistentes ou
+// de que o usuário não participa, e com 500 para os demais erros
+func writeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, `{"error": "Conversa não encontrada"}`, http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// otherMembers remove repetições e o próprio usuário da lista de participantes
+func otherMembers(userID int, ids []int) []int {
+	var others []int
+	seen := map[int]bool{userID: true}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			others = append(others, id)
+		}
+	}
+	return others
+}
@@ -0,0 +1,94 @@
+// conversation_test.go
+package conversation_test
+
+import (
+	"edsb/api/apitest"
+	"edsb/api/conversation"
+	"edsb/events"
+	"edsb/models"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// drain retorna os eventos já entregues à assinatura
+func drain(sub *events.Subscription) []events.Event {
+	var received []events.Event
+	for {
+		select {
+		case e := <-sub.C:
+			received = append(received, e)
+		default:
+			return received
+		}
+	}
+}
+
+func TestMuteSuppressesUnreadNotice(t *testing.T) {
+	srv := apitest.NewServer(t)
+	ana, bia := srv.User(t, "ana", ""), srv.User(t, "bia", "")
+
+	conv := models.Conversation{CreatedBy: ana.ID}
+	if err := srv.Store.Conversations.Create(&conv, []int{ana.ID, bia.ID}); err != nil {
+		t.Fatal(err)
+	}
+	path := fmt.Sprintf("/conversations/%d", conv.ID)
+	unread := fmt.Sprintf("unread-conversations-%d", conv.ID)
+
+	// Uma conexão de bia que não assina a conversa, como a barra de avisos
+	sub := srv.Hub.Subscribe(events.Filter{UserID: bia.ID})
+	defer sub.Close()
+
+	send := func(content string) {
+		t.Helper()
+		if status := ana.Do(t, "POST", path+"/messages", fmt.Sprintf(`{"content": %q}`, content), nil); status != http.StatusCreated {
+			t.Fatalf("POST mensagem = %d", status)
+		}
+	}
+
+	send("primeira")
+	send("segunda")
+	received := drain(sub)
+	if len(received) != 2 {
+		t.Fatalf("eventos = %+v, esperado dois avisos", received)
+	}
+	for i, e := range received {
+		notice, ok := e.Data.(conversation.Unread)
+		if e.Name != unread || !ok || notice.ConversationID != conv.ID || notice.UnreadCount != i+1 {
+			t.Fatalf("evento %d = %+v, esperado %s com unread_count %d", i, e, unread, i+1)
+		}
+	}
+
+	if status := bia.Do(t, "POST", path+"/mute", "", nil); status != http.StatusNoContent {
+		t.Fatalf("mute = %d", status)
+	}
+	send("terceira")
+	if received := drain(sub); len(received) != 0 {
+		t.Fatalf("eventos com a conversa silenciada = %+v, esperado nenhum", received)
+	}
+
+	// Quem assina a conversa continua recebendo as mensagens
+	conn := srv.Hub.Subscribe(events.Filter{UserID: bia.ID, ConversationIDs: map[int]bool{conv.ID: true}})
+	defer conn.Close()
+	send("quarta")
+	if received := drain(conn); len(received) != 1 || received[0].Name != fmt.Sprintf("messages-conversations-%d", conv.ID) {
+		t.Fatalf("eventos da conversa assinada = %+v, esperado só a mensagem", received)
+	}
+
+	if status := bia.Do(t, "DELETE", path+"/mute", "", nil); status != http.StatusNoContent {
+		t.Fatalf("unmute = %d", status)
+	}
+	drain(sub)
+	send("quinta")
+	if received := drain(sub); len(received) != 1 || received[0].Name != unread {
+		t.Fatalf("eventos depois do unmute = %+v, esperado o aviso", received)
+	}
+
+	// Quem envia não recebe aviso da própria mensagem
+	own := srv.Hub.Subscribe(events.Filter{UserID: ana.ID})
+	defer own.Close()
+	send("sexta")
+	if received := drain(own); len(received) != 0 {
+		t.Fatalf("eventos para quem enviou = %+v, esperado nenhum", received)
+	}
+}
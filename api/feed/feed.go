@@ -0,0 +1,36 @@
+// feed.go
+package feed
+
+import (
+	"edsb/api/auth"
+	"edsb/api/pagination"
+	"edsb/store"
+	"edsb/views"
+	"net/http"
+)
+
+// Handler para obter a linha do tempo do usuário autenticado: seus posts e os
+// de quem ele segue, do mais novo para o mais antigo
+func GetFeed(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		page, ok := pagination.FromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		user := auth.UserFromContext(r.Context())
+		posts, next, err := s.Feed.Home(user.ID, page)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if views.IsHTMX(r) {
+			views.RenderPartial(w, http.StatusOK, "post-list", views.PostList{Posts: posts, NextURL: views.NextURL(r, next)})
+			return
+		}
+		pagination.Write(w, posts, next)
+	}
+}
@@ -0,0 +1,125 @@
+// follow.go
+package follow
+
+import (
+	"edsb/api/auth"
+	"edsb/api/notification"
+	"edsb/api/pagination"
+	"edsb/events"
+	"edsb/models"
+	"edsb/store"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler para o usuário autenticado seguir o usuário do path
+func FollowUser(s *store.Store, hub *events.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		followerID := auth.UserFromContext(r.Context()).ID
+		followedID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, `{"error": "Usuário não encontrado"}`, http.StatusNotFound)
+			return
+		}
+
+		if followerID == followedID {
+			http.Error(w, `{"error": "Não é possível seguir a si mesmo"}`, http.StatusBadRequest)
+			return
+		}
+
+		follow, err := s.Follows.Follow(followerID, followedID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, `{"error": "Usuário não encontrado"}`, http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, store.ErrConflict) {
+				http.Error(w, `{"error": "Você já segue este usuário"}`, http.StatusConflict)
+				return
+			}
+			http.Error(w, `{"error": "Erro ao seguir usuário"}`, http.StatusInternalServerError)
+			return
+		}
+		notification.Followed(s, hub, followerID, followedID, true)
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(follow)
+	}
+}
+
+// Handler para o usuário autenticado deixar de seguir o usuário do path
+func UnfollowUser(s *store.Store, hub *events.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		followerID := auth.UserFromContext(r.Context()).ID
+		followedID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, `{"error": "Usuário não encontrado"}`, http.StatusNotFound)
+			return
+		}
+
+		// Deixar de seguir quem não se segue não é erro
+		removed, err := s.Follows.Unfollow(followerID, followedID)
+		if err != nil {
+			http.Error(w, `{"error": "Erro ao deixar de seguir usuário"}`, http.StatusInternalServerError)
+			return
+		}
+		if removed {
+			notification.Followed(s, hub, followerID, followedID, false)
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"message": "Você deixou de seguir o usuário"})
+	}
+}
+
+// Handler para listar os seguidores de um usuário
+func GetFollowers(s *store.Store) http.HandlerFunc {
+	return listConnections(s, s.Follows.Followers)
+}
+
+// Handler para listar quem um usuário segue
+func GetFollowing(s *store.Store) http.HandlerFunc {
+	return listConnections(s, s.Follows.Following)
+}
+
+// listConnections responde com uma página de list para o usuário do path
+func listConnections(s *store.Store, list func(userID int, page store.Page) ([]models.Connection, *store.Cursor, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, `{"error": "Usuário não encontrado"}`, http.StatusNotFound)
+			return
+		}
+
+		page, ok := pagination.FromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		if _, err := s.Users.Get(id); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, `{"error": "Usuário não encontrado"}`, http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		connections, next, err := list(id, page)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		pagination.Write(w, connections, next)
+	}
+}
@@ -0,0 +1,47 @@
+// follow_test.go
+package follow_test
+
+import (
+	"edsb/api/apitest"
+	"edsb/models"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestRemovedUsersLeaveFollowCounts(t *testing.T) {
+	srv := apitest.NewServer(t)
+	ana, bia, carla := srv.User(t, "ana", ""), srv.User(t, "bia", ""), srv.User(t, "carla", "")
+
+	for _, c := range []*apitest.Client{bia, carla} {
+		if status := c.Do(t, "POST", fmt.Sprintf("/users/%d/follow", ana.ID), "", nil); status != http.StatusCreated {
+			t.Fatalf("POST follow = %d, esperado 201", status)
+		}
+	}
+	if status := ana.Do(t, "POST", fmt.Sprintf("/users/%d/follow", carla.ID), "", nil); status != http.StatusCreated {
+		t.Fatalf("POST follow = %d, esperado 201", status)
+	}
+	if err := srv.Store.Users.Delete(carla.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Os contadores batem com as listagens, que já não mostram carla
+	var profile models.Profile
+	if status := bia.Do(t, "GET", fmt.Sprintf("/users/%d", ana.ID), "", &profile); status != http.StatusOK {
+		t.Fatalf("GET perfil = %d", status)
+	}
+	if profile.FollowersCount != 1 || profile.FollowingCount != 0 {
+		t.Fatalf("perfil = %+v, esperado 1 seguidor e 0 seguidos", profile)
+	}
+	var followers struct {
+		Data []models.Connection `json:"data"`
+	}
+	bia.Do(t, "GET", fmt.Sprintf("/users/%d/followers", ana.ID), "", &followers)
+	if len(followers.Data) != 1 || followers.Data[0].ID != bia.ID {
+		t.Fatalf("seguidores = %+v, esperado só bia", followers.Data)
+	}
+
+	if status := bia.Do(t, "POST", fmt.Sprintf("/users/%d/follow", carla.ID), "", nil); status != http.StatusNotFound {
+		t.Fatalf("seguir usuário removido = %d, esperado 404", status)
+	}
+}
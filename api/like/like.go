@@ -2,210 +2,234 @@
 package like
 
 import (
-	"database/sql"
+	"edsb/api/auth"
+	"edsb/api/notification"
+	"edsb/events"
+	"edsb/models"
+	"edsb/store"
+	"edsb/views"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
-	//"edsb/models"
-)
 
-// Cria a tabela de likes para posts e comentários
-func CreateLikesTable(db *sql.DB) error {
-	query := `
-	CREATE TABLE IF NOT EXISTS likes (
-		id SERIAL PRIMARY KEY,
-		user_id INT REFERENCES users(id) ON DELETE CASCADE,
-		post_id INT REFERENCES posts(id) ON DELETE CASCADE,
-		comment_id INT REFERENCES comments(id) ON DELETE CASCADE,
-		UNIQUE(user_id, post_id),
-		UNIQUE(user_id, comment_id)
-	);`
-	if _, err := db.Exec(query); err != nil {
-		return err
-	}
-	log.Println("Tabela likes criada com sucesso (se não existia).")
-	return nil
-}
+	"github.com/gorilla/mux"
+)
 
 // Handler para adicionar um like a um post
-func AddLikeToPost(db *sql.DB) http.HandlerFunc {
+func AddLikeToPost(s *store.Store, hub *events.Hub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		if err := r.ParseForm(); err != nil {
-			http.Error(w, `{"error": "Erro ao processar o formulário"}`, http.StatusBadRequest)
-			return
-		}
-
-		userID, err := strconv.Atoi(r.FormValue("user_id"))
-		if err != nil || userID == 0 {
-			http.Error(w, `{"error": "ID do usuário é obrigatório e deve ser um número"}`, http.StatusBadRequest)
-			return
-		}
 
-		postID, err := strconv.Atoi(r.FormValue("post_id"))
-		if err != nil || postID == 0 {
-			http.Error(w, `{"error": "ID do post é obrigatório e deve ser um número"}`, http.StatusBadRequest)
+		userID, postID, ok := likeParams(w, r, `{"error": "ID do post deve ser um número"}`)
+		if !ok {
 			return
 		}
 
-		query := "INSERT INTO likes (user_id, post_id) VALUES ($1, $2)"
-		_, err = db.Exec(query, userID, postID)
+		like, created, err := s.Likes.LikePost(userID, postID)
 		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, `{"error": "Post não encontrado"}`, http.StatusNotFound)
+				return
+			}
 			http.Error(w, `{"error": "Erro ao adicionar like"}`, http.StatusInternalServerError)
 			return
 		}
+		if created {
+			notification.PostLiked(s, hub, userID, postID, true)
+			publishLikes(s, hub, "posts", postID)
+		}
 
-		// Incrementar a contagem de likes do post
-		updateQuery := "UPDATE posts SET likes = likes + 1 WHERE id = $1"
-		_, err = db.Exec(updateQuery, postID)
-		if err != nil {
-			http.Error(w, `{"error": "Erro ao incrementar o like no post"}`, http.StatusInternalServerError)
+		// Curtir de novo não é erro: devolve o like existente com 200
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+		}
+		if views.IsHTMX(r) {
+			writeLikeButton(w, status, "posts", postID, true, s.Likes.CountForPost)
 			return
 		}
-
-		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(map[string]string{"message": "Like adicionado com sucesso"})
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(like)
 	}
 }
 
 // Handler para adicionar um like a um comentário
-func AddLikeToComment(db *sql.DB) http.HandlerFunc {
+func AddLikeToComment(s *store.Store, hub *events.Hub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		if err := r.ParseForm(); err != nil {
-			http.Error(w, `{"error": "Erro ao processar o formulário"}`, http.StatusBadRequest)
-			return
-		}
-
-		userID, err := strconv.Atoi(r.FormValue("user_id"))
-		if err != nil || userID == 0 {
-			http.Error(w, `{"error": "ID do usuário é obrigatório e deve ser um número"}`, http.StatusBadRequest)
-			return
-		}
 
-		commentID, err := strconv.Atoi(r.FormValue("comment_id"))
-		if err != nil || commentID == 0 {
-			http.Error(w, `{"error": "ID do comentário é obrigatório e deve ser um número"}`, http.StatusBadRequest)
+		userID, commentID, ok := likeParams(w, r, `{"error": "ID do comentário deve ser um número"}`)
+		if !ok {
 			return
 		}
 
-		query := "INSERT INTO likes (user_id, comment_id) VALUES ($1, $2)"
-		_, err = db.Exec(query, userID, commentID)
+		like, created, err := s.Likes.LikeComment(userID, commentID)
 		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, `{"error": "Comentário não encontrado"}`, http.StatusNotFound)
+				return
+			}
 			http.Error(w, `{"error": "Erro ao adicionar like"}`, http.StatusInternalServerError)
 			return
 		}
+		if created {
+			notification.CommentLiked(s, hub, userID, commentID, true)
+			publishLikes(s, hub, "comments", commentID)
+		}
 
-		// Incrementar a contagem de likes do comentário
-		updateQuery := "UPDATE comments SET likes = likes + 1 WHERE id = $1"
-		_, err = db.Exec(updateQuery, commentID)
-		if err != nil {
-			http.Error(w, `{"error": "Erro ao incrementar o like no comentário"}`, http.StatusInternalServerError)
+		// Curtir de novo não é erro: devolve o like existente com 200
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+		}
+		if views.IsHTMX(r) {
+			writeLikeButton(w, status, "comments", commentID, true, s.Likes.CountForComment)
 			return
 		}
-
-		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(map[string]string{"message": "Like adicionado com sucesso"})
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(like)
 	}
 }
 
 // Handler para remover um like de um post
-func RemoveLikeFromPost(db *sql.DB) http.HandlerFunc {
+func RemoveLikeFromPost(s *store.Store, hub *events.Hub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		if err := r.ParseForm(); err != nil {
-			http.Error(w, `{"error": "Erro ao processar o formulário"}`, http.StatusBadRequest)
+
+		userID, postID, ok := likeParams(w, r, `{"error": "ID do post deve ser um número"}`)
+		if !ok {
 			return
 		}
 
-		userID, err := strconv.Atoi(r.FormValue("user_id"))
-		if err != nil || userID == 0 {
-			http.Error(w, `{"error": "ID do usuário é obrigatório e deve ser um número"}`, http.StatusBadRequest)
+		// Remover um like inexistente não é erro
+		removed, err := s.Likes.UnlikePost(userID, postID)
+		if err != nil {
+			http.Error(w, `{"error": "Erro ao remover like"}`, http.StatusInternalServerError)
 			return
 		}
+		if removed {
+			notification.PostLiked(s, hub, userID, postID, false)
+			publishLikes(s, hub, "posts", postID)
+		}
 
-		postID, err := strconv.Atoi(r.FormValue("post_id"))
-		if err != nil || postID == 0 {
-			http.Error(w, `{"error": "ID do post é obrigatório e deve ser um número"}`, http.StatusBadRequest)
+		if views.IsHTMX(r) {
+			writeLikeButton(w, http.StatusOK, "posts", postID, false, s.Likes.CountForPost)
 			return
 		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Like removido com sucesso"})
+	}
+}
 
-		query := "DELETE FROM likes WHERE user_id = $1 AND post_id = $2"
-		_, err = db.Exec(query, userID, postID)
-		if err != nil {
-			http.Error(w, `{"error": "Erro ao remover like"}`, http.StatusInternalServerError)
+// Handler para remover um like de um comentário
+func RemoveLikeFromComment(s *store.Store, hub *events.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		userID, commentID, ok := likeParams(w, r, `{"error": "ID do comentário deve ser um número"}`)
+		if !ok {
 			return
 		}
 
-		// Decrementar a contagem de likes do post
-		updateQuery := "UPDATE posts SET likes = likes - 1 WHERE id = $1"
-		_, err = db.Exec(updateQuery, postID)
+		// Remover um like inexistente não é erro
+		removed, err := s.Likes.UnlikeComment(userID, commentID)
 		if err != nil {
-			http.Error(w, `{"error": "Erro ao decrementar o like no post"}`, http.StatusInternalServerError)
+			http.Error(w, `{"error": "Erro ao remover like"}`, http.StatusInternalServerError)
 			return
 		}
+		if removed {
+			notification.CommentLiked(s, hub, userID, commentID, false)
+			publishLikes(s, hub, "comments", commentID)
+		}
 
+		if views.IsHTMX(r) {
+			writeLikeButton(w, http.StatusOK, "comments", commentID, false, s.Likes.CountForComment)
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(map[string]string{"message": "Like removido com sucesso"})
 	}
 }
 
-// Handler para remover um like de um comentário
-func RemoveLikeFromComment(db *sql.DB) http.HandlerFunc {
+// Handler para alternar (curtir/descurtir) o like de um post
+func ToggleLikeOnPost(s *store.Store, hub *events.Hub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		if err := r.ParseForm(); err != nil {
-			http.Error(w, `{"error": "Erro ao processar o formulário"}`, http.StatusBadRequest)
+
+		userID, postID, ok := likeParams(w, r, `{"error": "ID do post deve ser um número"}`)
+		if !ok {
 			return
 		}
 
-		userID, err := strconv.Atoi(r.FormValue("user_id"))
-		if err != nil || userID == 0 {
-			http.Error(w, `{"error": "ID do usuário é obrigatório e deve ser um número"}`, http.StatusBadRequest)
+		liked, count, err := s.Likes.TogglePost(userID, postID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, `{"error": "Post não encontrado"}`, http.StatusNotFound)
+				return
+			}
+			http.Error(w, `{"error": "Erro ao alternar like"}`, http.StatusInternalServerError)
 			return
 		}
+		notification.PostLiked(s, hub, userID, postID, liked)
+		publishLikes(s, hub, "posts", postID)
 
-		commentID, err := strconv.Atoi(r.FormValue("comment_id"))
-		if err != nil || commentID == 0 {
-			http.Error(w, `{"error": "ID do comentário é obrigatório e deve ser um número"}`, http.StatusBadRequest)
+		if views.IsHTMX(r) {
+			views.RenderPartial(w, http.StatusOK, "like-button", views.LikeButton{Target: "posts", ID: postID, Count: count, Liked: liked})
 			return
 		}
+		json.NewEncoder(w).Encode(map[string]any{"liked": liked, "likes_count": count})
+	}
+}
 
-		query := "DELETE FROM likes WHERE user_id = $1 AND comment_id = $2"
-		_, err = db.Exec(query, userID, commentID)
-		if err != nil {
-			http.Error(w, `{"error": "Erro ao remover like"}`, http.StatusInternalServerError)
+// Handler para alternar (curtir/descurtir) o like de um comentário
+func ToggleLikeOnComment(s *store.Store, hub *events.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		userID, commentID, ok := likeParams(w, r, `{"error": "ID do comentário deve ser um número"}`)
+		if !ok {
 			return
 		}
 
-		// Decrementar a contagem de likes do comentário
-		updateQuery := "UPDATE comments SET likes = likes - 1 WHERE id = $1"
-		_, err = db.Exec(updateQuery, commentID)
+		liked, count, err := s.Likes.ToggleComment(userID, commentID)
 		if err != nil {
-			http.Error(w, `{"error": "Erro ao decrementar o like no comentário"}`, http.StatusInternalServerError)
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, `{"error": "Comentário não encontrado"}`, http.StatusNotFound)
+				return
+			}
+			http.Error(w, `{"error": "Erro ao alternar like"}`, http.StatusInternalServerError)
 			return
 		}
+		notification.CommentLiked(s, hub, userID, commentID, liked)
+		publishLikes(s, hub, "comments", commentID)
 
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(map[string]string{"message": "Like removido com sucesso"})
+		if views.IsHTMX(r) {
+			views.RenderPartial(w, http.StatusOK, "like-button", views.LikeButton{Target: "comments", ID: commentID, Count: count, Liked: liked})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"liked": liked, "likes_count": count})
 	}
 }
 
 // Handler para contar likes de um post
-func CountLikesForPost(db *sql.DB) http.HandlerFunc {
+func CountLikesForPost(s *store.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		postID, err := strconv.Atoi(r.URL.Query().Get("post_id"))
-		if err != nil || postID == 0 {
-			http.Error(w, `{"error": "ID do post é obrigatório e deve ser um número"}`, http.StatusBadRequest)
+		postID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, `{"error": "ID do post deve ser um número"}`, http.StatusBadRequest)
 			return
 		}
 
-		var count int
-		query := `SELECT likes FROM posts WHERE id = $1`
-		err = db.QueryRow(query, postID).Scan(&count)
+		count, err := s.Likes.CountForPost(postID)
 		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, `{"error": "Post não encontrado"}`, http.StatusNotFound)
+				return
+			}
 			http.Error(w, `{"error": "Erro ao contar likes"}`, http.StatusInternalServerError)
 			return
 		}
@@ -215,19 +239,21 @@ func CountLikesForPost(db *sql.DB) http.HandlerFunc {
 }
 
 // Handler para contar likes de um comentário
-func CountLikesForComment(db *sql.DB) http.HandlerFunc {
+func CountLikesForComment(s *store.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		commentID, err := strconv.Atoi(r.URL.Query().Get("comment_id"))
-		if err != nil || commentID == 0 {
-			http.Error(w, `{"error": "ID do comentário é obrigatório e deve ser um número"}`, http.StatusBadRequest)
+		commentID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, `{"error": "ID do comentário deve ser um número"}`, http.StatusBadRequest)
 			return
 		}
 
-		var count int
-		query := `SELECT likes FROM comments WHERE id = $1`
-		err = db.QueryRow(query, commentID).Scan(&count)
+		count, err := s.Likes.CountForComment(commentID)
 		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, `{"error": "Comentário não encontrado"}`, http.StatusNotFound)
+				return
+			}
 			http.Error(w, `{"error": "Erro ao contar likes"}`, http.StatusInternalServerError)
 			return
 		}
@@ -235,3 +261,62 @@ func CountLikesForComment(db *sql.DB) http.HandlerFunc {
 		json.NewEncoder(w).Encode(map[string]int{"likes": count})
 	}
 }
+
+// likeParams extrai o usuário autenticado e o ID do alvo ({id} no path). O
+// like é sempre do usuário autenticado: um user_id diferente no formulário é
+// recusado. Em caso de erro a resposta já é escrita e ok é false.
+func likeParams(w http.ResponseWriter, r *http.Request, invalidID string) (userID, targetID int, ok bool) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, `{"error": "Erro ao processar o formulário"}`, http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	userID = auth.UserFromContext(r.Context()).ID
+	if v := r.FormValue("user_id"); v != "" && v != strconv.Itoa(userID) {
+		http.Error(w, `{"error": "Não é permitido curtir em nome de outro usuário"}`, http.StatusForbidden)
+		return 0, 0, false
+	}
+
+	targetID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, invalidID, http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, targetID, true
+}
+
+// writeLikeButton responde ao htmx com o botão de like do alvo já atualizado
+func writeLikeButton(w http.ResponseWriter, status int, target string, id int, liked bool, count func(int) (int, error)) {
+	n, err := count(id)
+	if err != nil {
+		http.Error(w, `{"error": "Erro ao contar likes"}`, http.StatusInternalServerError)
+		return
+	}
+	views.RenderPartial(w, status, "like-button", views.LikeButton{Target: target, ID: id, Count: n, Liked: liked})
+}
+
+// publishLikes avisa os clientes conectados em /events da nova contagem de
+// likes do alvo ("posts" ou "comments"), no evento "likes-{alvo}-{id}"
+func publishLikes(s *store.Store, hub *events.Hub, target string, id int) {
+	postID, count := id, 0
+	var err error
+	if target == "comments" {
+		var comment *models.Comment
+		if comment, err = s.Comments.Get(id); err == nil {
+			postID, count = comment.PostID, comment.LikesCount
+		}
+	} else {
+		count, err = s.Likes.CountForPost(id)
+	}
+	if err != nil {
+		log.Printf("Erro ao publicar likes de %s/%d: %v", target, id, err)
+		return
+	}
+
+	hub.Publish(events.Event{
+		Name:   fmt.Sprintf("likes-%s-%d", target, id),
+		PostID: postID,
+		Data:   map[string]any{"target": target, "id": id, "likes_count": count},
+		HTML:   strconv.Itoa(count),
+	})
+}
@@ -0,0 +1,132 @@
+// like_test.go
+package like_test
+
+import (
+	"edsb/api/apitest"
+	"edsb/models"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// likeCount consulta o contador público de likes do alvo
+func likeCount(t *testing.T, c *apitest.Client, target string, id int) int {
+	t.Helper()
+	var count struct {
+		Likes int `json:"likes"`
+	}
+	if status := c.Do(t, "GET", fmt.Sprintf("/%s/%d/likes/count", target, id), "", &count); status != http.StatusOK {
+		t.Fatalf("GET likes/count = %d", status)
+	}
+	return count.Likes
+}
+
+func newPost(t *testing.T, c *apitest.Client) int {
+	t.Helper()
+	var post models.Post
+	if status := c.Do(t, "POST", "/posts", `{"title": "Título", "content": "Conteúdo"}`, &post); status != http.StatusCreated {
+		t.Fatalf("POST /posts = %d", status)
+	}
+	return post.ID
+}
+
+func TestLikeIsIdempotent(t *testing.T) {
+	srv := apitest.NewServer(t)
+	ana, bia := srv.User(t, "ana", ""), srv.User(t, "bia", "")
+	postID := newPost(t, ana)
+	path := fmt.Sprintf("/posts/%d/like", postID)
+
+	var first, second models.Like
+	if status := bia.Do(t, "POST", path, "", &first); status != http.StatusCreated {
+		t.Fatalf("primeiro like = %d, esperado 201", status)
+	}
+	if status := bia.Do(t, "POST", path, "", &second); status != http.StatusOK {
+		t.Fatalf("like repetido = %d, esperado 200", status)
+	}
+	if second.ID != first.ID {
+		t.Fatalf("like repetido criou outro like: %d != %d", second.ID, first.ID)
+	}
+	if n := likeCount(t, srv.Anonymous(), "posts", postID); n != 1 {
+		t.Fatalf("likes = %d, esperado 1", n)
+	}
+
+	ana.Do(t, "POST", path, "", nil)
+	if n := likeCount(t, srv.Anonymous(), "posts", postID); n != 2 {
+		t.Fatalf("likes = %d, esperado 2", n)
+	}
+
+	// Remover duas vezes também não é erro e só decrementa uma vez
+	for i := 0; i < 2; i++ {
+		if status := bia.Do(t, "DELETE", path, "", nil); status != http.StatusOK {
+			t.Fatalf("DELETE like = %d, esperado 200", status)
+		}
+	}
+	if n := likeCount(t, srv.Anonymous(), "posts", postID); n != 1 {
+		t.Fatalf("likes = %d, esperado 1", n)
+	}
+
+	if status := bia.Do(t, "POST", "/posts/999/like", "", nil); status != http.StatusNotFound {
+		t.Fatalf("like em post inexistente = %d, esperado 404", status)
+	}
+}
+
+func TestToggleLikeCounts(t *testing.T) {
+	srv := apitest.NewServer(t)
+	ana, bia := srv.User(t, "ana", ""), srv.User(t, "bia", "")
+	postID := newPost(t, ana)
+
+	var comment models.Comment
+	body := fmt.Sprintf(`{"post_id": %d, "content": "Comentário"}`, postID)
+	if status := ana.Do(t, "POST", "/comments", body, &comment); status != http.StatusCreated {
+		t.Fatalf("POST /comments = %d", status)
+	}
+
+	for _, target := range []struct {
+		name string
+		id   int
+	}{{"posts", postID}, {"comments", comment.ID}} {
+		path := fmt.Sprintf("/%s/%d/like", target.name, target.id)
+		steps := []struct {
+			client *apitest.Client
+			liked  bool
+			count  int
+		}{
+			{ana, true, 1},
+			{bia, true, 2},
+			{ana, false, 1},
+			{ana, true, 2},
+			{bia, false, 1},
+		}
+		for i, step := range steps {
+			var got struct {
+				Liked      bool `json:"liked"`
+				LikesCount int  `json:"likes_count"`
+			}
+			if status := step.client.Do(t, "PUT", path, "", &got); status != http.StatusOK {
+				t.Fatalf("%s passo %d: PUT = %d", target.name, i, status)
+			}
+			if got.Liked != step.liked || got.LikesCount != step.count {
+				t.Fatalf("%s passo %d: liked=%v likes_count=%d, esperado liked=%v likes_count=%d",
+					target.name, i, got.Liked, got.LikesCount, step.liked, step.count)
+			}
+		}
+		if n := likeCount(t, srv.Anonymous(), target.name, target.id); n != 1 {
+			t.Fatalf("%s: likes = %d, esperado 1", target.name, n)
+		}
+	}
+}
+
+func TestLikeRemovedPost(t *testing.T) {
+	srv := apitest.NewServer(t)
+	ana := srv.User(t, "ana", "")
+	postID := newPost(t, ana)
+
+	if status := ana.Do(t, "DELETE", fmt.Sprintf("/posts/%d", postID), "", nil); status != http.StatusNoContent {
+		t.Fatalf("DELETE post = %d", status)
+	}
+	for _, method := range []string{"POST", "PUT"} {
+		if status := ana.Do(t, method, fmt.Sprintf("/posts/%d/like", postID), "", nil); status != http.StatusNotFound {
+			t.Fatalf("%s like em post removido = %d, esperado 404", method, status)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+// mention.go
+package mention
+
+import (
+	"edsb/api/notification"
+	"edsb/events"
+	"edsb/models"
+	"edsb/store"
+	"log"
+)
+
+// SyncPost grava as menções (@username) do conteúdo do post e notifica quem
+// passou a ser mencionado. Quem deixou de ser mencionado numa edição perde a
+// notificação. Deve ser chamada depois de criar ou atualizar o post.
+func SyncPost(s *store.Store, hub *events.Hub, post models.Post) {
+	added, removed, err := s.Mentions.SetForPost(post.ID, models.ParseMentions(post.Content))
+	if err != nil {
+		log.Printf("Erro ao gravar menções do post %d: %v", post.ID, err)
+		return
+	}
+	notify(s, hub, post.UserID, added, removed, post.ID, 0)
+}
+
+// SyncComment é o equivalente de SyncPost para comentários
+func SyncComment(s *store.Store, hub *events.Hub, comment models.Comment) {
+	added, removed, err := s.Mentions.SetForComment(comment.ID, models.ParseMentions(comment.Content))
+	if err != nil {
+		log.Printf("Erro ao gravar menções do comentário %d: %v", comment.ID, err)
+		return
+	}
+	notify(s, hub, comment.UserID, added, removed, comment.PostID, comment.ID)
+}
+
+// notify envia a notificação aos novos mencionados e a retira de quem deixou
+// de ser mencionado
+func notify(s *store.Store, hub *events.Hub, actorID int, added, removed []int, postID, commentID int) {
+	for _, userID := range added {
+		notification.Send(s, hub, models.Notification{UserID: userID, ActorID: actorID, Type: models.NotificationMention, PostID: postID, CommentID: commentID})
+	}
+	for _, userID := range removed {
+		notification.Retract(s, models.Notification{UserID: userID, ActorID: actorID, Type: models.NotificationMention, PostID: postID, CommentID: commentID})
+	}
+}
@@ -0,0 +1,150 @@
+// notification.go
+package notification
+
+import (
+	"edsb/api/auth"
+	"edsb/api/pagination"
+	"edsb/models"
+	"edsb/store"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler para listar as notificações do usuário autenticado, agrupadas por
+// tipo e alvo ("Ana e mais 4 curtiram seu post"). Com ?unread=true lista
+// somente as não lidas.
+func GetNotifications(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		page, ok := pagination.FromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		unreadOnly := false
+		if v := r.URL.Query().Get("unread"); v != "" {
+			var err error
+			if unreadOnly, err = strconv.ParseBool(v); err != nil {
+				http.Error(w, `{"error": "Parâmetro unread inválido"}`, http.StatusBadRequest)
+				return
+			}
+		}
+
+		user := auth.UserFromContext(r.Context())
+		groups, next, err := s.Notifications.List(user.ID, unreadOnly, page)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for i := range groups {
+			groups[i].Message = groups[i].Describe()
+		}
+		pagination.Write(w, groups, next)
+	}
+}
+
+// Handler para marcar como lida uma notificação e as demais do seu grupo
+func MarkRead(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, `{"error": "Notificação não encontrada"}`, http.StatusNotFound)
+			return
+		}
+
+		user := auth.UserFromContext(r.Context())
+		marked, err := s.Notifications.MarkRead(user.ID, id)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, `{"error": "Notificação não encontrada"}`, http.StatusNotFound)
+				return
+			}
+			http.Error(w, `{"error": "Erro ao marcar notificação como lida"}`, http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]int64{"marked": marked})
+	}
+}
+
+// Handler para marcar como lidas todas as notificações do usuário autenticado
+func MarkAllRead(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		user := auth.UserFromContext(r.Context())
+		marked, err := s.Notifications.MarkAllRead(user.ID)
+		if err != nil {
+			http.Error(w, `{"error": "Erro ao marcar notificações como lidas"}`, http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]int64{"marked": marked})
+	}
+}
+
+// Handler para obter quais tipos de notificação o usuário autenticado recebe
+func GetPreferences(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		user := auth.UserFromContext(r.Context())
+		prefs, err := s.Notifications.Preferences(user.ID)
+		if err != nil {
+			http.Error(w, `{"error": "Erro ao carregar preferências"}`, http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(prefs)
+	}
+}
+
+// Handler para ativar ou desativar tipos de notificação. O corpo é um objeto
+// com os tipos a alterar, ex.: {"like": false, "follow": true}.
+func UpdatePreferences(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var changes map[string]bool
+		if err := json.NewDecoder(r.Body).Decode(&changes); err != nil {
+			http.Error(w, `{"error": "Corpo da requisição inválido"}`, http.StatusBadRequest)
+			return
+		}
+		for kind := range changes {
+			if !validType(kind) {
+				http.Error(w, `{"error": "Tipo de notificação desconhecido"}`, http.StatusBadRequest)
+				return
+			}
+		}
+
+		user := auth.UserFromContext(r.Context())
+		if err := s.Notifications.SetPreferences(user.ID, changes); err != nil {
+			http.Error(w, `{"error": "Erro ao salvar preferências"}`, http.StatusInternalServerError)
+			return
+		}
+
+		prefs, err := s.Notifications.Preferences(user.ID)
+		if err != nil {
+			http.Error(w, `{"error": "Erro ao carregar preferências"}`, http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(prefs)
+	}
+}
+
+// validType indica se kind é um dos models.NotificationTypes
+func validType(kind string) bool {
+	for _, t := range models.NotificationTypes {
+		if t == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+// notify.go
+package notification
+
+import (
+	"edsb/events"
+	"edsb/models"
+	"edsb/store"
+	"log"
+)
+
+// Send grava a notificação, a menos que o usuário tenha desativado o tipo, e
+// a publica no hub para o destinatário. Ninguém é notificado pelo que fez no
+// próprio conteúdo. Notificar é um efeito secundário da ação do usuário,
+// então erros são apenas registrados.
+func Send(s *store.Store, hub *events.Hub, n models.Notification) {
+	if n.UserID == 0 || n.UserID == n.ActorID {
+		return
+	}
+	created, err := s.Notifications.Create(&n)
+	if err != nil {
+		log.Printf("Erro ao notificar o usuário %d (%s): %v", n.UserID, n.Type, err)
+		return
+	}
+	if created {
+		hub.Publish(events.Event{Name: "notification", UserID: n.UserID, Data: n})
+	}
+}
+
+// Retract apaga a notificação enviada por Send quando a ação é desfeita
+func Retract(s *store.Store, n models.Notification) {
+	if err := s.Notifications.Remove(n); err != nil {
+		log.Printf("Erro ao remover notificação do usuário %d (%s): %v", n.UserID, n.Type, err)
+	}
+}
+
+// PostLiked notifica o autor do post sobre o like (liked) ou retira a
+// notificação quando o like é desfeito
+func PostLiked(s *store.Store, hub *events.Hub, actorID, postID int, liked bool) {
+	post, err := s.Posts.Get(postID)
+	if err != nil {
+		log.Printf("Erro ao notificar like no post %d: %v", postID, err)
+		return
+	}
+	toggle(s, hub, liked, models.Notification{UserID: post.UserID, ActorID: actorID, Type: models.NotificationLike, PostID: postID})
+}
+
+// CommentLiked é o equivalente de PostLiked para comentários
+func CommentLiked(s *store.Store, hub *events.Hub, actorID, commentID int, liked bool) {
+	comment, err := s.Comments.Get(commentID)
+	if err != nil {
+		log.Printf("Erro ao notificar like no comentário %d: %v", commentID, err)
+		return
+	}
+	toggle(s, hub, liked, models.Notification{UserID: comment.UserID, ActorID: actorID, Type: models.NotificationLike, PostID: comment.PostID, CommentID: commentID})
+}
+
+// Followed notifica quem passou a ser seguido, ou retira a notificação quando
+// o usuário deixa de seguir
+func Followed(s *store.Store, hub *events.Hub, followerID, followedID int, following bool) {
+	toggle(s, hub, following, models.Notification{UserID: followedID, ActorID: followerID, Type: models.NotificationFollow})
+}
+
+// Commented notifica o autor do post sobre um novo comentário ou, se for uma
+// resposta, o autor do comentário respondido
+func Commented(s *store.Store, hub *events.Hub, comment models.Comment) {
+	if comment.ParentID != nil {
+		parent, err := s.Comments.Get(*comment.ParentID)
+		if err != nil {
+			log.Printf("Erro ao notificar resposta ao comentário %d: %v", *comment.ParentID, err)
+			return
+		}
+		Send(s, hub, models.Notification{UserID: parent.UserID, ActorID: comment.UserID, Type: models.NotificationReply, PostID: comment.PostID, CommentID: parent.ID})
+		return
+	}
+
+	post, err := s.Posts.Get(comment.PostID)
+	if err != nil {
+		log.Printf("Erro ao notificar comentário no post %d: %v", comment.PostID, err)
+		return
+	}
+	Send(s, hub, models.Notification{UserID: post.UserID, ActorID: comment.UserID, Type: models.NotificationComment, PostID: comment.PostID})
+}
+
+func toggle(s *store.Store, hub *events.Hub, on bool, n models.Notification) {
+	if on {
+		Send(s, hub, n)
+		return
+	}
+	Retract(s, n)
+}
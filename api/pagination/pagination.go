@@ -0,0 +1,51 @@
+// pagination.go
+package pagination
+
+import (
+	"edsb/store"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// Response é o envelope comum das listagens paginadas
+type Response struct {
+	Data       any     `json:"data"`
+	NextCursor *string `json:"next_cursor"` // null quando não há próxima página
+}
+
+// FromRequest lê os parâmetros ?limit= e ?cursor= da requisição. Em caso de
+// erro a resposta 400 já é escrita e ok é false.
+func FromRequest(w http.ResponseWriter, r *http.Request) (page store.Page, ok bool) {
+	page.Limit = store.DefaultLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			http.Error(w, `{"error": "limit deve ser um número positivo"}`, http.StatusBadRequest)
+			return page, false
+		}
+		page.Limit = min(limit, store.MaxLimit)
+	}
+
+	if v := r.URL.Query().Get("cursor"); v != "" {
+		cursor, err := store.DecodeCursor(v)
+		if err != nil {
+			http.Error(w, `{"error": "Cursor inválido"}`, http.StatusBadRequest)
+			return page, false
+		}
+		page.After = cursor
+	}
+	return page, true
+}
+
+// Write responde com o envelope paginado em JSON
+func Write(w http.ResponseWriter, data any, next *store.Cursor) {
+	resp := Response{Data: data}
+	if next != nil {
+		token := next.Encode()
+		resp.NextCursor = &token
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -2,123 +2,188 @@
 package post
 
 import (
-	"database/sql"
+	"edsb/api/auth"
+	"edsb/api/mention"
+	"edsb/api/pagination"
+	"edsb/events"
 	"edsb/models"
+	"edsb/store"
+	"edsb/views"
 	"encoding/json"
-	"log"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
-// Cria a tabela de posts com referência ao usuário
-func CreatePostsTable(db *sql.DB) error {
-	query := `
-	CREATE TABLE IF NOT EXISTS posts (
-		id SERIAL PRIMARY KEY,
-		user_id INT REFERENCES users(id) ON DELETE CASCADE,
-		title TEXT NOT NULL,
-		content TEXT NOT NULL,
-		likes_count INT DEFAULT 0,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);`
-
-	if _, err := db.Exec(query); err != nil {
-		return err
-	}
-	log.Println("Tabela posts criada com sucesso (se não existia).")
-	return nil
+// windows são as janelas de tempo aceitas em ?window=; zero é sem limite
+var windows = map[string]time.Duration{
+	"day":  24 * time.Hour,
+	"week": 7 * 24 * time.Hour,
+	"all":  0,
 }
 
-// Handler para obter todos os posts
-func GetPosts(db *sql.DB) http.HandlerFunc {
+// Handler para obter todos os posts. ?sort=new (padrão) lista do mais novo para
+// o mais antigo, ?sort=hot pondera engajamento e idade e ?sort=top ordena pelo
+// engajamento total; ?window=day|week|all (padrão all) limita a data de
+// publicação.
+func GetPosts(s *store.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		rows, err := db.Query("SELECT id, user_id, title, content, created_at FROM posts")
+		w.Header().Set("Content-Type", "application/json")
+
+		query := store.PostQuery{Sort: store.PostSort(r.URL.Query().Get("sort"))}
+		switch query.Sort {
+		case "":
+			query.Sort = store.SortNew
+		case store.SortNew, store.SortHot, store.SortTop:
+		default:
+			http.Error(w, `{"error": "sort deve ser hot, top ou new"}`, http.StatusBadRequest)
+			return
+		}
+
+		window := r.URL.Query().Get("window")
+		if window == "" {
+			window = "all"
+		}
+		d, ok := windows[window]
+		if !ok {
+			http.Error(w, `{"error": "window deve ser day, week ou all"}`, http.StatusBadRequest)
+			return
+		}
+		if d > 0 {
+			query.Since = time.Now().Add(-d)
+		}
+
+		page, ok := pagination.FromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		posts, next, err := s.Posts.List(query, page)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		defer rows.Close()
 
-		var posts []models.Post
-		for rows.Next() {
-			var post models.Post
-			if err := rows.Scan(&post.ID, &post.UserID, &post.Title, &post.Content, &post.CreatedAt); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-			posts = append(posts, post)
+		if views.IsHTMX(r) {
+			views.RenderPartial(w, http.StatusOK, "post-list", views.PostList{Posts: posts, NextURL: views.NextURL(r, next)})
+			return
 		}
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(posts)
+		pagination.Write(w, posts, next)
 	}
 }
 
 // Handler para obter um post específico
-func GetPost(db *sql.DB) http.HandlerFunc {
+func GetPost(s *store.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id := mux.Vars(r)["id"]
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, `{"error": "Post não encontrado"}`, http.StatusNotFound)
+			return
+		}
 
-		var post models.Post
-		query := "SELECT id, user_id, title, content, created_at FROM posts WHERE id = $1"
-		if err := db.QueryRow(query, id).Scan(&post.ID, &post.UserID, &post.Title, &post.Content, &post.CreatedAt); err != nil {
-			http.Error(w, err.Error(), http.StatusNotFound)
+		post, err := s.Posts.Get(id)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, `{"error": "Post não encontrado"}`, http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
+		if post.Reactions, err = s.Reactions.CountsForPost(post.ID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if views.IsHTMX(r) {
+			views.RenderPartial(w, http.StatusOK, "post-card", post)
+			return
+		}
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(post)
 	}
 }
 
 // Handler para criar um novo post
-func CreatePost(db *sql.DB) http.HandlerFunc {
+func CreatePost(s *store.Store, hub *events.Hub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		user := auth.UserFromContext(r.Context())
+
 		var post models.Post
 		if err := json.NewDecoder(r.Body).Decode(&post); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		query := "INSERT INTO posts (user_id, title, content) VALUES ($1, $2, $3) RETURNING id, created_at"
-		err := db.QueryRow(query, post.UserID, post.Title, post.Content).Scan(&post.ID, &post.CreatedAt)
-		if err != nil {
+		// O autor é sempre o usuário autenticado
+		if post.UserID != 0 && post.UserID != user.ID {
+			http.Error(w, `{"error": "Não é permitido publicar em nome de outro usuário"}`, http.StatusForbidden)
+			return
+		}
+		post.UserID = user.ID
+		post.Tags = models.ParseHashtags(post.Content)
+
+		if err := s.Posts.Create(&post); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		mention.SyncPost(s, hub, post)
 
+		if views.IsHTMX(r) {
+			views.RenderPartial(w, http.StatusCreated, "post-card", post)
+			return
+		}
 		w.WriteHeader(http.StatusCreated)
 		json.NewEncoder(w).Encode(post)
 	}
 }
 
 // Handler para atualizar um post existente
-func UpdatePost(db *sql.DB) http.HandlerFunc {
+func UpdatePost(s *store.Store, hub *events.Hub) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id := mux.Vars(r)["id"]
+		id, ok := authorizePost(s, w, r)
+		if !ok {
+			return
+		}
+
 		var post models.Post
 		if err := json.NewDecoder(r.Body).Decode(&post); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		query := "UPDATE posts SET title = $1, content = $2 WHERE id = $3"
-		if _, err := db.Exec(query, post.Title, post.Content, id); err != nil {
+		post.ID = id
+		post.Tags = models.ParseHashtags(post.Content)
+		if err := s.Posts.Update(&post, auth.UserFromContext(r.Context()).ID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// As menções são atribuídas ao autor, mesmo quando um moderador edita
+		updated, err := s.Posts.Get(id)
+		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		mention.SyncPost(s, hub, *updated)
 
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
 
 // Handler para deletar um post
-func DeletePost(db *sql.DB) http.HandlerFunc {
+func DeletePost(s *store.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id := mux.Vars(r)["id"]
-		query := "DELETE FROM posts WHERE id = $1"
-		if _, err := db.Exec(query, id); err != nil {
+		id, ok := authorizePost(s, w, r)
+		if !ok {
+			return
+		}
+
+		if err := s.Posts.Delete(id); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -126,3 +191,76 @@ func DeletePost(db *sql.DB) http.HandlerFunc {
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+// Handler para restaurar um post removido. Somente o autor ou um
+// administrador podem restaurá-lo, e apenas dentro de models.RestoreWindow.
+func RestorePost(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, `{"error": "Post removido não encontrado"}`, http.StatusNotFound)
+			return
+		}
+
+		deleted, err := s.Posts.Deleted(id)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, `{"error": "Post removido não encontrado"}`, http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if !auth.CanModify(auth.UserFromContext(r.Context()), deleted.UserID, models.RoleAdmin) {
+			auth.Forbidden(w)
+			return
+		}
+		if !models.Restorable(*deleted.DeletedAt) {
+			http.Error(w, `{"error": "Prazo para restauração expirado"}`, http.StatusGone)
+			return
+		}
+
+		if err := s.Posts.Restore(id); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		post, err := s.Posts.Get(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(post)
+	}
+}
+
+// authorizePost verifica se o post do path existe e se quem faz a requisição
+// pode alterá-lo (o autor, um moderador ou um administrador). Em caso negativo
+// a resposta de erro já é escrita e ok é false.
+func authorizePost(s *store.Store, w http.ResponseWriter, r *http.Request) (id int, ok bool) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error": "Post não encontrado"}`, http.StatusNotFound)
+		return 0, false
+	}
+
+	post, err := s.Posts.Get(id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, `{"error": "Post não encontrado"}`, http.StatusNotFound)
+			return 0, false
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return 0, false
+	}
+
+	if !auth.CanModify(auth.UserFromContext(r.Context()), post.UserID, models.RoleModerator, models.RoleAdmin) {
+		auth.Forbidden(w)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,130 @@
+// post_test.go
+package post_test
+
+import (
+	"edsb/api/apitest"
+	"edsb/models"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type postPage struct {
+	Data       []models.Post `json:"data"`
+	NextCursor *string       `json:"next_cursor"`
+}
+
+func newPost(t *testing.T, c *apitest.Client, title string) int {
+	t.Helper()
+	var post models.Post
+	body := fmt.Sprintf(`{"title": %q, "content": "Conteúdo"}`, title)
+	if status := c.Do(t, "POST", "/posts", body, &post); status != http.StatusCreated {
+		t.Fatalf("POST /posts = %d", status)
+	}
+	return post.ID
+}
+
+// listIDs percorre GET /posts página a página seguindo next_cursor
+func listIDs(t *testing.T, c *apitest.Client, limit int) (ids []int, pages int) {
+	t.Helper()
+	path := fmt.Sprintf("/posts?limit=%d", limit)
+	for {
+		var page postPage
+		if status := c.Do(t, "GET", path, "", &page); status != http.StatusOK {
+			t.Fatalf("GET %s = %d", path, status)
+		}
+		if len(page.Data) > limit {
+			t.Fatalf("página com %d posts, limite %d", len(page.Data), limit)
+		}
+		pages++
+		for _, p := range page.Data {
+			ids = append(ids, p.ID)
+		}
+		if page.NextCursor == nil {
+			return ids, pages
+		}
+		path = fmt.Sprintf("/posts?limit=%d&cursor=%s", limit, url.QueryEscape(*page.NextCursor))
+	}
+}
+
+func TestGetPostsCursorPaging(t *testing.T) {
+	srv := apitest.NewServer(t)
+	ana := srv.User(t, "ana", "")
+	for i := 1; i <= 5; i++ {
+		newPost(t, ana, fmt.Sprintf("Post %d", i))
+	}
+
+	ids, pages := listIDs(t, srv.Anonymous(), 2)
+	if fmt.Sprint(ids) != "[5 4 3 2 1]" || pages != 3 {
+		t.Fatalf("ids = %v em %d páginas, esperado [5 4 3 2 1] em 3", ids, pages)
+	}
+
+	// Um post criado durante a paginação não desloca as páginas seguintes
+	var first postPage
+	srv.Anonymous().Do(t, "GET", "/posts?limit=2", "", &first)
+	newPost(t, ana, "Post 6")
+	var second postPage
+	srv.Anonymous().Do(t, "GET", "/posts?limit=2&cursor="+url.QueryEscape(*first.NextCursor), "", &second)
+	if len(second.Data) != 2 || second.Data[0].ID != 3 || second.Data[1].ID != 2 {
+		t.Fatalf("segunda página = %+v, esperado os posts 3 e 2", second.Data)
+	}
+
+	for _, query := range []string{"limit=0", "limit=x", "cursor=invalido"} {
+		if status := srv.Anonymous().Do(t, "GET", "/posts?"+query, "", nil); status != http.StatusBadRequest {
+			t.Fatalf("GET /posts?%s = %d, esperado 400", query, status)
+		}
+	}
+}
+
+func TestDeleteAndRestorePost(t *testing.T) {
+	srv := apitest.NewServer(t)
+	ana, bia := srv.User(t, "ana", ""), srv.User(t, "bia", "")
+	admin := srv.User(t, "admin", models.RoleAdmin)
+	postID := newPost(t, ana, "Post")
+	path := fmt.Sprintf("/posts/%d", postID)
+
+	if status := bia.Do(t, "DELETE", path, "", nil); status != http.StatusForbidden {
+		t.Fatalf("DELETE por outro usuário = %d, esperado 403", status)
+	}
+	if status := ana.Do(t, "DELETE", path, "", nil); status != http.StatusNoContent {
+		t.Fatalf("DELETE = %d, esperado 204", status)
+	}
+	if status := ana.Do(t, "GET", path, "", nil); status != http.StatusNotFound {
+		t.Fatalf("GET post removido = %d, esperado 404", status)
+	}
+	if ids, _ := listIDs(t, ana, 10); len(ids) != 0 {
+		t.Fatalf("listagem = %v, esperado vazia", ids)
+	}
+
+	if status := bia.Do(t, "POST", path+"/restore", "", nil); status != http.StatusForbidden {
+		t.Fatalf("restore por outro usuário = %d, esperado 403", status)
+	}
+	var restored models.Post
+	if status := ana.Do(t, "POST", path+"/restore", "", &restored); status != http.StatusOK {
+		t.Fatalf("restore = %d, esperado 200", status)
+	}
+	if restored.ID != postID || restored.DeletedAt != nil {
+		t.Fatalf("post restaurado = %+v", restored)
+	}
+	if status := ana.Do(t, "POST", path+"/restore", "", nil); status != http.StatusNotFound {
+		t.Fatalf("restore de post não removido = %d, esperado 404", status)
+	}
+
+	// Um administrador também restaura, mas só dentro do prazo
+	ana.Do(t, "DELETE", path, "", nil)
+	window := models.RestoreWindow
+	t.Cleanup(func() { models.RestoreWindow = window })
+	models.RestoreWindow = 0
+	if status := admin.Do(t, "POST", path+"/restore", "", nil); status != http.StatusGone {
+		t.Fatalf("restore fora do prazo = %d, esperado 410", status)
+	}
+	models.RestoreWindow = time.Hour
+	if status := admin.Do(t, "POST", path+"/restore", "", nil); status != http.StatusOK {
+		t.Fatalf("restore pelo administrador = %d, esperado 200", status)
+	}
+	if status := ana.Do(t, "GET", path, "", nil); status != http.StatusOK {
+		t.Fatalf("GET post restaurado = %d, esperado 200", status)
+	}
+}
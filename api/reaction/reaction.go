@@ -0,0 +1,179 @@
+// reaction.go
+package reaction
+
+import (
+	"edsb/api/auth"
+	"edsb/models"
+	"edsb/store"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler para reagir a um post (substitui a reação anterior do usuário)
+func ReactToPost(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		postID, emoji, ok := reactionParams(w, r, `{"error": "ID do post deve ser um número"}`)
+		if !ok {
+			return
+		}
+
+		reaction, err := s.Reactions.ReactToPost(auth.UserFromContext(r.Context()).ID, postID, emoji)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, `{"error": "Post não encontrado"}`, http.StatusNotFound)
+				return
+			}
+			http.Error(w, `{"error": "Erro ao registrar reação"}`, http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(reaction)
+	}
+}
+
+// Handler para reagir a um comentário (substitui a reação anterior do usuário)
+func ReactToComment(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		commentID, emoji, ok := reactionParams(w, r, `{"error": "ID do comentário deve ser um número"}`)
+		if !ok {
+			return
+		}
+
+		reaction, err := s.Reactions.ReactToComment(auth.UserFromContext(r.Context()).ID, commentID, emoji)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, `{"error": "Comentário não encontrado"}`, http.StatusNotFound)
+				return
+			}
+			http.Error(w, `{"error": "Erro ao registrar reação"}`, http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(reaction)
+	}
+}
+
+// Handler para remover a reação do usuário a um post
+func RemoveReactionFromPost(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		postID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, `{"error": "ID do post deve ser um número"}`, http.StatusBadRequest)
+			return
+		}
+
+		// Remover uma reação inexistente não é erro
+		if _, err := s.Reactions.RemoveFromPost(auth.UserFromContext(r.Context()).ID, postID); err != nil {
+			http.Error(w, `{"error": "Erro ao remover reação"}`, http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"message": "Reação removida com sucesso"})
+	}
+}
+
+// Handler para remover a reação do usuário a um comentário
+func RemoveReactionFromComment(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		commentID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, `{"error": "ID do comentário deve ser um número"}`, http.StatusBadRequest)
+			return
+		}
+
+		// Remover uma reação inexistente não é erro
+		if _, err := s.Reactions.RemoveFromComment(auth.UserFromContext(r.Context()).ID, commentID); err != nil {
+			http.Error(w, `{"error": "Erro ao remover reação"}`, http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"message": "Reação removida com sucesso"})
+	}
+}
+
+// Handler para listar quem reagiu a um post e com qual emoji (?emoji= filtra)
+func GetPostReactions(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		postID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, `{"error": "ID do post deve ser um número"}`, http.StatusBadRequest)
+			return
+		}
+		if _, err := s.Posts.Get(postID); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, `{"error": "Post não encontrado"}`, http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		reactions, err := s.Reactions.ListForPost(postID, r.URL.Query().Get("emoji"))
+		if err != nil {
+			http.Error(w, `{"error": "Erro ao listar reações"}`, http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(reactions)
+	}
+}
+
+// Handler para listar quem reagiu a um comentário e com qual emoji (?emoji= filtra)
+func GetCommentReactions(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		commentID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, `{"error": "ID do comentário deve ser um número"}`, http.StatusBadRequest)
+			return
+		}
+		if _, err := s.Comments.Get(commentID); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, `{"error": "Comentário não encontrado"}`, http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		reactions, err := s.Reactions.ListForComment(commentID, r.URL.Query().Get("emoji"))
+		if err != nil {
+			http.Error(w, `{"error": "Erro ao listar reações"}`, http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(reactions)
+	}
+}
+
+// reactionParams extrai o ID do alvo ({id} no path) e valida o emoji enviado
+// no formulário. Em caso de erro a resposta já é escrita e ok é false.
+func reactionParams(w http.ResponseWriter, r *http.Request, invalidID string) (targetID int, emoji string, ok bool) {
+	targetID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, invalidID, http.StatusBadRequest)
+		return 0, "", false
+	}
+
+	emoji = r.FormValue("emoji")
+	if !models.ValidReaction(emoji) {
+		http.Error(w, `{"error": "Reação inválida"}`, http.StatusBadRequest)
+		return 0, "", false
+	}
+	return targetID, emoji, true
+}
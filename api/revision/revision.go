@@ -0,0 +1,138 @@
+// revision.go
+package revision
+
+import (
+	"edsb/diff"
+	"edsb/models"
+	"edsb/store"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// Diff é a comparação entre duas versões de um post ou comentário
+type Diff struct {
+	From    int           `json:"from"`
+	To      int           `json:"to"`
+	Mode    string        `json:"mode"`            // "line" ou "word", como o conteúdo foi comparado
+	Title   []diff.Change `json:"title,omitempty"` // comparado palavra a palavra; ausente em comentários
+	Content []diff.Change `json:"content"`
+}
+
+// Handler para listar o histórico de edições de um post, da versão mais nova
+// para a mais antiga
+func GetPostRevisions(s *store.Store) http.HandlerFunc {
+	return listRevisions(s.Posts.Revisions, `{"error": "Post não encontrado"}`)
+}
+
+// Handler para listar o histórico de edições de um comentário, da versão mais
+// nova para a mais antiga
+func GetCommentRevisions(s *store.Store) http.HandlerFunc {
+	return listRevisions(s.Comments.Revisions, `{"error": "Comentário não encontrado"}`)
+}
+
+// Handler para comparar uma versão de um post com a anterior ou, com
+// ?from=N, com a versão N. ?mode=word compara o conteúdo palavra a palavra em
+// vez de linha a linha.
+func DiffPostRevision(s *store.Store) http.HandlerFunc {
+	return diffRevisions(s.Posts.Revision, true)
+}
+
+// Handler para comparar uma versão de um comentário com a anterior ou, com
+// ?from=N, com a versão N. Aceita ?mode=word como DiffPostRevision.
+func DiffCommentRevision(s *store.Store) http.HandlerFunc {
+	return diffRevisions(s.Comments.Revision, false)
+}
+
+func listRevisions(revisions func(id int) ([]models.Revision, error), notFound string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, notFound, http.StatusNotFound)
+			return
+		}
+
+		list, err := revisions(id)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, notFound, http.StatusNotFound)
+				return
+			}
+			http.Error(w, `{"error": "Erro ao carregar histórico"}`, http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+func diffRevisions(revision func(id, version int) (*models.Revision, error), withTitle bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, `{"error": "Versão não encontrada"}`, http.StatusNotFound)
+			return
+		}
+		to, err := strconv.Atoi(mux.Vars(r)["version"])
+		if err != nil {
+			http.Error(w, `{"error": "Versão não encontrada"}`, http.StatusNotFound)
+			return
+		}
+
+		from := to - 1
+		if v := r.URL.Query().Get("from"); v != "" {
+			if from, err = strconv.Atoi(v); err != nil {
+				http.Error(w, `{"error": "Parâmetro from inválido"}`, http.StatusBadRequest)
+				return
+			}
+		} else if to == 1 {
+			http.Error(w, `{"error": "A versão 1 é a original; informe ?from para compará-la"}`, http.StatusBadRequest)
+			return
+		}
+
+		mode := r.URL.Query().Get("mode")
+		compare := diff.Lines
+		switch mode {
+		case "", "line":
+			mode = "line"
+		case "word":
+			compare = diff.Words
+		default:
+			http.Error(w, `{"error": "Parâmetro mode inválido (use line ou word)"}`, http.StatusBadRequest)
+			return
+		}
+
+		newer, err := revision(id, to)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		older, err := revision(id, from)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		d := Diff{From: from, To: to, Mode: mode, Content: compare(older.Content, newer.Content)}
+		if withTitle {
+			d.Title = diff.Words(older.Title, newer.Title)
+		}
+		json.NewEncoder(w).Encode(d)
+	}
+}
+
+// writeError responde com 404 para versões inexistentes e com 500 para os
+// demais erros
+func writeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, `{"error": "Versão não encontrada"}`, http.StatusNotFound)
+		return
+	}
+	http.Error(w, `{"error": "Erro ao carregar versão"}`, http.StatusInternalServerError)
+}
@@ -2,44 +2,124 @@
 package routes
 
 import (
-	"database/sql"
+	"edsb/api/auth"
 	"edsb/api/comment"
+	"edsb/api/conversation"
+	"edsb/api/feed"
+	"edsb/api/follow"
 	"edsb/api/like"
+	"edsb/api/notification"
 	"edsb/api/post"
+	"edsb/api/reaction"
+	"edsb/api/revision"
+	"edsb/api/search"
+	"edsb/api/sse"
+	"edsb/api/tag"
 	"edsb/api/user"
+	"edsb/api/ws"
+	"edsb/events"
+	"edsb/store"
 
 	"github.com/gorilla/mux"
 )
 
-// ConfigureRoutes define todas as rotas da aplicação
-func ConfigureRoutes(r *mux.Router, db *sql.DB) {
+// ConfigureRoutes define todas as rotas da aplicação. As atualizações em
+// tempo real (comentários, likes e notificações) são publicadas em hub.
+func ConfigureRoutes(r *mux.Router, s *store.Store, hub *events.Hub) {
+
+	// Identifica o usuário da sessão em todas as requisições
+	r.Use(auth.Middleware(s))
 
 	// Rotas para usuários
-	r.HandleFunc("/users", user.GetUsers(db)).Methods("GET")
-	r.HandleFunc("/users/{id}", user.GetUser(db)).Methods("GET")
-	r.HandleFunc("/users/register", user.CreateUser(db)).Methods("POST")
-	r.HandleFunc("/users/login", user.LoginUser(db)).Methods("POST")
-	r.HandleFunc("/users/{id}", user.UpdateUser(db)).Methods("PUT")
-	r.HandleFunc("/users/{id}", user.DeleteUser(db)).Methods("DELETE")
+	r.HandleFunc("/users", user.GetUsers(s)).Methods("GET")
+	r.HandleFunc("/users/{id}", user.GetUser(s)).Methods("GET")
+	r.HandleFunc("/users/register", user.CreateUser(s)).Methods("POST")
+	r.HandleFunc("/users/login", user.LoginUser(s)).Methods("POST")
+	r.HandleFunc("/users/logout", user.LogoutUser(s)).Methods("POST")
+	r.HandleFunc("/users/{id}", auth.RequireUser(user.UpdateUser(s))).Methods("PUT")
+	r.HandleFunc("/users/{id}", auth.RequireUser(user.DeleteUser(s))).Methods("DELETE")
+	r.HandleFunc("/users/{id}/restore", auth.RequireUser(user.RestoreUser(s))).Methods("POST")
+	r.HandleFunc("/u/{username}", user.ShowProfile(s)).Methods("GET")
+
+	// Rotas para o grafo social (seguidores)
+	r.HandleFunc("/users/{id}/follow", auth.RequireUser(follow.FollowUser(s, hub))).Methods("POST")
+	r.HandleFunc("/users/{id}/follow", auth.RequireUser(follow.UnfollowUser(s, hub))).Methods("DELETE")
+	r.HandleFunc("/users/{id}/followers", follow.GetFollowers(s)).Methods("GET")
+	r.HandleFunc("/users/{id}/following", follow.GetFollowing(s)).Methods("GET")
 
 	// Rotas para posts
-	r.HandleFunc("/posts", post.GetPosts(db)).Methods("GET")
-	r.HandleFunc("/posts/{id}", post.GetPost(db)).Methods("GET")
-	r.HandleFunc("/posts", post.CreatePost(db)).Methods("POST")
-	r.HandleFunc("/posts/{id}", post.UpdatePost(db)).Methods("PUT")
-	r.HandleFunc("/posts/{id}", post.DeletePost(db)).Methods("DELETE")
+	r.HandleFunc("/posts", post.GetPosts(s)).Methods("GET")
+	r.HandleFunc("/posts/{id}", post.GetPost(s)).Methods("GET")
+	r.HandleFunc("/posts", auth.RequireUser(post.CreatePost(s, hub))).Methods("POST")
+	r.HandleFunc("/posts/{id}", auth.RequireUser(post.UpdatePost(s, hub))).Methods("PUT")
+	r.HandleFunc("/posts/{id}", auth.RequireUser(post.DeletePost(s))).Methods("DELETE")
+	r.HandleFunc("/posts/{id}/restore", auth.RequireUser(post.RestorePost(s))).Methods("POST")
+	r.HandleFunc("/posts/{id}/comments", comment.GetPostComments(s)).Methods("GET")
+
+	// Linha do tempo do usuário autenticado
+	r.HandleFunc("/feed", auth.RequireUser(feed.GetFeed(s))).Methods("GET")
+
+	// Busca textual em posts e comentários
+	r.HandleFunc("/search", search.Search(s)).Methods("GET")
+
+	// Rotas para hashtags
+	r.HandleFunc("/tags/trending", tag.GetTrendingTags(s)).Methods("GET")
+	r.HandleFunc("/tags/{tag}/posts", tag.GetTagPosts(s)).Methods("GET")
+
+	// Atualizações em tempo real (Server-Sent Events)
+	r.HandleFunc("/events", sse.Events(hub)).Methods("GET")
+
+	// Gateway WebSocket para conversas e comentários ao vivo
+	r.HandleFunc("/ws", auth.RequireUser(ws.Gateway(s, hub))).Methods("GET")
+
+	// Notificações do usuário autenticado
+	r.HandleFunc("/notifications", auth.RequireUser(notification.GetNotifications(s))).Methods("GET")
+	r.HandleFunc("/notifications/read", auth.RequireUser(notification.MarkAllRead(s))).Methods("POST")
+	r.HandleFunc("/notifications/{id}/read", auth.RequireUser(notification.MarkRead(s))).Methods("POST")
+	r.HandleFunc("/notifications/preferences", auth.RequireUser(notification.GetPreferences(s))).Methods("GET")
+	r.HandleFunc("/notifications/preferences", auth.RequireUser(notification.UpdatePreferences(s))).Methods("PUT")
+
+	// Mensagens privadas
+	r.HandleFunc("/conversations", auth.RequireUser(conversation.GetConversations(s))).Methods("GET")
+	r.HandleFunc("/conversations", auth.RequireUser(conversation.StartConversation(s))).Methods("POST")
+	r.HandleFunc("/conversations/{id}", auth.RequireUser(conversation.GetConversation(s))).Methods("GET")
+	r.HandleFunc("/conversations/{id}/messages", auth.RequireUser(conversation.GetMessages(s))).Methods("GET")
+	r.HandleFunc("/conversations/{id}/messages", auth.RequireUser(conversation.SendMessage(s, hub))).Methods("POST")
+	r.HandleFunc("/conversations/{id}/read", auth.RequireUser(conversation.MarkRead(s))).Methods("POST")
+	r.HandleFunc("/conversations/{id}/mute", auth.RequireUser(conversation.MuteConversation(s))).Methods("POST")
+	r.HandleFunc("/conversations/{id}/mute", auth.RequireUser(conversation.UnmuteConversation(s))).Methods("DELETE")
+	r.HandleFunc("/conversations/{id}/leave", auth.RequireUser(conversation.LeaveConversation(s))).Methods("POST")
 
 	// Rotas para comentários
-	r.HandleFunc("/comments", comment.GetComments(db)).Methods("GET")
-	r.HandleFunc("/comments/{id}", comment.GetComment(db)).Methods("GET")
-	r.HandleFunc("/comments", comment.CreateComment(db)).Methods("POST")
-	r.HandleFunc("/comments/{id}", comment.UpdateComment(db)).Methods("PUT")
-	r.HandleFunc("/comments/{id}", comment.DeleteComment(db)).Methods("DELETE")
+	r.HandleFunc("/comments", comment.GetComments(s)).Methods("GET")
+	r.HandleFunc("/comments/{id}", comment.GetComment(s)).Methods("GET")
+	r.HandleFunc("/comments", auth.RequireUser(comment.CreateComment(s, hub))).Methods("POST")
+	r.HandleFunc("/comments/{id}", auth.RequireUser(comment.UpdateComment(s, hub))).Methods("PUT")
+	r.HandleFunc("/comments/{id}", auth.RequireUser(comment.DeleteComment(s))).Methods("DELETE")
+	r.HandleFunc("/comments/{id}/restore", auth.RequireUser(comment.RestoreComment(s))).Methods("POST")
 
 	// Rotas para likes em posts e comentários
-	r.HandleFunc("/posts/{id}/like", like.AddLikeToPost(db)).Methods("POST")
-	r.HandleFunc("/posts/{id}/likes/count", like.CountLikesForPost(db)).Methods("GET")
-	r.HandleFunc("/comments/{id}/like", like.AddLikeToComment(db)).Methods("POST")
-	r.HandleFunc("/comments/{id}/likes/count", like.CountLikesForComment(db)).Methods("GET")
+	r.HandleFunc("/posts/{id}/like", auth.RequireUser(like.AddLikeToPost(s, hub))).Methods("POST")
+	r.HandleFunc("/posts/{id}/like", auth.RequireUser(like.RemoveLikeFromPost(s, hub))).Methods("DELETE")
+	r.HandleFunc("/posts/{id}/like", auth.RequireUser(like.ToggleLikeOnPost(s, hub))).Methods("PUT")
+	r.HandleFunc("/posts/{id}/likes/count", like.CountLikesForPost(s)).Methods("GET")
+	r.HandleFunc("/comments/{id}/like", auth.RequireUser(like.AddLikeToComment(s, hub))).Methods("POST")
+	r.HandleFunc("/comments/{id}/like", auth.RequireUser(like.RemoveLikeFromComment(s, hub))).Methods("DELETE")
+	r.HandleFunc("/comments/{id}/like", auth.RequireUser(like.ToggleLikeOnComment(s, hub))).Methods("PUT")
+	r.HandleFunc("/comments/{id}/likes/count", like.CountLikesForComment(s)).Methods("GET")
+
+	// Rotas para reações (emojis) em posts e comentários
+	r.HandleFunc("/posts/{id}/reaction", auth.RequireUser(reaction.ReactToPost(s))).Methods("PUT")
+	r.HandleFunc("/posts/{id}/reaction", auth.RequireUser(reaction.RemoveReactionFromPost(s))).Methods("DELETE")
+	r.HandleFunc("/posts/{id}/reactions", reaction.GetPostReactions(s)).Methods("GET")
+	r.HandleFunc("/comments/{id}/reaction", auth.RequireUser(reaction.ReactToComment(s))).Methods("PUT")
+	r.HandleFunc("/comments/{id}/reaction", auth.RequireUser(reaction.RemoveReactionFromComment(s))).Methods("DELETE")
+	r.HandleFunc("/comments/{id}/reactions", reaction.GetCommentReactions(s)).Methods("GET")
+
+	// Histórico de edições de posts e comentários
+	r.HandleFunc("/posts/{id}/revisions", revision.GetPostRevisions(s)).Methods("GET")
+	r.HandleFunc("/posts/{id}/revisions/{version}/diff", revision.DiffPostRevision(s)).Methods("GET")
+	r.HandleFunc("/comments/{id}/revisions", revision.GetCommentRevisions(s)).Methods("GET")
+	r.HandleFunc("/comments/{id}/revisions/{version}/diff", revision.DiffCommentRevision(s)).Methods("GET")
 
 }
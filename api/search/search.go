@@ -0,0 +1,75 @@
+// search.go
+package search
+
+import (
+	"edsb/api/pagination"
+	"edsb/models"
+	"edsb/store"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateLayout é o formato aceito em ?from= e ?to=
+const dateLayout = "2006-01-02"
+
+// Handler para buscar posts e comentários. ?q= aceita a sintaxe de busca web
+// ("frase exata", termo1 or termo2, -excluido); os filtros opcionais são
+// ?type=post|comment, ?author= (ID do usuário) e ?from= / ?to= (AAAA-MM-DD,
+// inclusivos). Os resultados vêm do mais para o menos relevante.
+func Search(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		params := r.URL.Query()
+
+		query := store.SearchQuery{Text: strings.TrimSpace(params.Get("q")), Type: params.Get("type")}
+		if query.Text == "" {
+			http.Error(w, `{"error": "Informe o que buscar em q"}`, http.StatusBadRequest)
+			return
+		}
+		if query.Type != "" && query.Type != models.SearchPost && query.Type != models.SearchComment {
+			http.Error(w, `{"error": "type deve ser post ou comment"}`, http.StatusBadRequest)
+			return
+		}
+
+		if v := params.Get("author"); v != "" {
+			id, err := strconv.Atoi(v)
+			if err != nil || id <= 0 {
+				http.Error(w, `{"error": "author deve ser o ID de um usuário"}`, http.StatusBadRequest)
+				return
+			}
+			query.AuthorID = id
+		}
+
+		if v := params.Get("from"); v != "" {
+			from, err := time.Parse(dateLayout, v)
+			if err != nil {
+				http.Error(w, `{"error": "from deve estar no formato AAAA-MM-DD"}`, http.StatusBadRequest)
+				return
+			}
+			query.From = from
+		}
+		if v := params.Get("to"); v != "" {
+			to, err := time.Parse(dateLayout, v)
+			if err != nil {
+				http.Error(w, `{"error": "to deve estar no formato AAAA-MM-DD"}`, http.StatusBadRequest)
+				return
+			}
+			query.To = to.AddDate(0, 0, 1) // inclui o dia inteiro
+		}
+
+		page, ok := pagination.FromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		results, next, err := s.Search.Search(query, page)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		pagination.Write(w, results, next)
+	}
+}
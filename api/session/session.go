@@ -0,0 +1,106 @@
+// session.go
+package session
+
+import (
+	"edsb/models"
+	"edsb/store"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const (
+	// CookieName é o nome do cookie que carrega o ID da sessão
+	CookieName = "edsb_session"
+
+	// TTL é o tempo de vida de uma sessão sem atividade
+	TTL = 7 * 24 * time.Hour
+
+	// RenewThreshold define quando a sessão é renovada: se faltar menos
+	// que isso para expirar, a validade volta a ser de TTL (sliding expiration)
+	RenewThreshold = TTL / 2
+)
+
+// ErrNoSession indica que a requisição não possui uma sessão válida
+var ErrNoSession = errors.New("sessão inexistente ou expirada")
+
+// Create abre uma nova sessão para o usuário, aproveitando para limpar as
+// sessões expiradas dele
+func Create(s *store.Store, userID int) (*models.Session, error) {
+	if err := s.Sessions.DeleteExpired(userID); err != nil {
+		return nil, err
+	}
+	return s.Sessions.Create(userID, TTL)
+}
+
+// Delete encerra uma sessão
+func Delete(s *store.Store, id string) error {
+	return s.Sessions.Delete(id)
+}
+
+// SetCookie grava o cookie HttpOnly da sessão na resposta
+func SetCookie(w http.ResponseWriter, r *http.Request, s *models.Session) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     CookieName,
+		Value:    s.ID,
+		Path:     "/",
+		Expires:  s.ExpiresAt,
+		MaxAge:   int(time.Until(s.ExpiresAt).Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// ClearCookie instrui o navegador a descartar o cookie da sessão
+func ClearCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     CookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// FromRequest retorna a sessão válida associada ao cookie da requisição
+func FromRequest(s *store.Store, r *http.Request) (*models.Session, error) {
+	cookie, err := r.Cookie(CookieName)
+	if err != nil || cookie.Value == "" {
+		return nil, ErrNoSession
+	}
+
+	sess, err := s.Sessions.Get(cookie.Value)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrNoSession
+	}
+	return sess, err
+}
+
+// CurrentUser resolve o usuário logado a partir da requisição, renovando a
+// sessão (e o cookie) quando ela está próxima de expirar
+func CurrentUser(s *store.Store, w http.ResponseWriter, r *http.Request) (*models.User, error) {
+	sess, err := FromRequest(s, r)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.Users.Get(sess.UserID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ErrNoSession
+		}
+		return nil, err
+	}
+
+	renewed, err := s.Sessions.Renew(sess, TTL, RenewThreshold)
+	if err != nil {
+		return nil, err
+	}
+	if renewed {
+		SetCookie(w, r, sess)
+	}
+	return user, nil
+}
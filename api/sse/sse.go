@@ -0,0 +1,107 @@
+// sse.go
+package sse
+
+import (
+	"edsb/api/auth"
+	"edsb/events"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HeartbeatInterval é o intervalo entre os comentários enviados para manter a
+// conexão aberta através de proxies
+var HeartbeatInterval = 15 * time.Second
+
+// Handler do stream de Server-Sent Events (GET /events). Envia os eventos
+// públicos e os destinados ao usuário autenticado; ?post=ID (repetível)
+// restringe os eventos de posts e ?format=html envia fragmentos HTML, para uso
+// com a extensão SSE do htmx (sse-connect="/events?format=html").
+func Events(hub *events.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, `{"error": "Streaming não suportado"}`, http.StatusInternalServerError)
+			return
+		}
+
+		var filter events.Filter
+		for _, v := range r.URL.Query()["post"] {
+			id, err := strconv.Atoi(v)
+			if err != nil {
+				w.Header().Set("Content-Type", "application/json")
+				http.Error(w, `{"error": "Parâmetro post inválido"}`, http.StatusBadRequest)
+				return
+			}
+			if filter.PostIDs == nil {
+				filter.PostIDs = map[int]bool{}
+			}
+			filter.PostIDs[id] = true
+		}
+		if user := auth.UserFromContext(r.Context()); user != nil {
+			filter.UserID = user.ID
+		}
+		html := r.URL.Query().Get("format") == "html"
+
+		sub := hub.Subscribe(filter)
+		defer sub.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no") // desativa o buffer do nginx
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, "retry: 5000\n\n")
+		flusher.Flush()
+
+		heartbeat := time.NewTicker(HeartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-heartbeat.C:
+				if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+			case e, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				if err := writeEvent(w, e, html); err != nil {
+					log.Printf("Erro ao enviar evento %s: %v", e.Name, err)
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent escreve o evento no formato do EventSource: cada linha do
+// conteúdo vira uma linha "data:"
+func writeEvent(w io.Writer, e events.Event, html bool) error {
+	data := strings.TrimSpace(e.HTML)
+	if !html || data == "" {
+		b, err := json.Marshal(e.Data)
+		if err != nil {
+			return err
+		}
+		data = string(b)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "event: %s\n", e.Name)
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,88 @@
+// tag.go
+package tag
+
+import (
+	"edsb/api/pagination"
+	"edsb/models"
+	"edsb/store"
+	"edsb/views"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// trendingWindows são as janelas aceitas em GET /tags/trending?window=
+var trendingWindows = map[string]time.Duration{
+	"hour": time.Hour,
+	"day":  24 * time.Hour,
+	"week": 7 * 24 * time.Hour,
+}
+
+const (
+	defaultTrendingLimit = 10
+	maxTrendingLimit     = 50
+)
+
+// Handler para listar os posts com uma hashtag ({tag}, com ou sem #)
+func GetTagPosts(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		page, ok := pagination.FromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		posts, next, err := s.Tags.Posts(models.NormalizeTag(mux.Vars(r)["tag"]), page)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if views.IsHTMX(r) {
+			views.RenderPartial(w, http.StatusOK, "post-list", views.PostList{Posts: posts, NextURL: views.NextURL(r, next)})
+			return
+		}
+		pagination.Write(w, posts, next)
+	}
+}
+
+// Handler para listar as hashtags em alta: as mais usadas na última janela
+// (?window=hour|day|week, padrão day), ordenadas pelo crescimento em relação
+// à janela anterior
+func GetTrendingTags(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		window := r.URL.Query().Get("window")
+		if window == "" {
+			window = "day"
+		}
+		d, ok := trendingWindows[window]
+		if !ok {
+			http.Error(w, `{"error": "window deve ser hour, day ou week"}`, http.StatusBadRequest)
+			return
+		}
+
+		limit := defaultTrendingLimit
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				http.Error(w, `{"error": "limit deve ser um número positivo"}`, http.StatusBadRequest)
+				return
+			}
+			limit = min(n, maxTrendingLimit)
+		}
+
+		tags, err := s.Tags.Trending(d, limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		json.NewEncoder(w).Encode(tags)
+	}
+}
@@ -2,73 +2,66 @@
 package user
 
 import (
-	"database/sql"
+	"edsb/api/auth"
+	"edsb/api/pagination"
+	"edsb/api/session"
 	"edsb/models"
+	"edsb/store"
+	"edsb/views"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 	"golang.org/x/crypto/bcrypt"
-	"github.com/google/uuid"
 )
 
-// Cria tabela de usuário
-func CreateUsersTable(db *sql.DB) error {
-	query := `
-	CREATE TABLE IF NOT EXISTS users (
-		id SERIAL PRIMARY KEY,
-		username VARCHAR(50) NOT NULL UNIQUE,
-		email VARCHAR(100) NOT NULL UNIQUE,
-		password_hash VARCHAR(255) NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);`
-
-	if _, err := db.Exec(query); err != nil {
-		return err
-	}
-	log.Println("Tabela users criada com sucesso (se não existia).")
-	return nil
-}
-
 // Registro de um novo usuario no banco de dados
-func RegisterUser(db *sql.DB, username, email, password string) error {
+func RegisterUser(users store.UserStore, username, email, password string) error {
 	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
-	query := `INSERT INTO users (username, email, password_hash) VALUES ($1, $2, $3)`
-	_, err = db.Exec(query, username, email, passwordHash)
-	if err != nil {
+	user := models.User{Username: username, Email: email}
+	if err := users.Create(&user, string(passwordHash)); err != nil {
 		return err
 	}
 	log.Println("Usuário registrado com sucesso.")
 	return nil
 }
 
-// Autenticação do usuário com base no email e senha fornecidos pelo mesmo
-func AuthenticateUser(db *sql.DB, email, password string) (bool, error) {
-	var storedHash string
+// Autenticação do usuário com base no email e senha fornecidos pelo mesmo.
+// Retorna o ID do usuário autenticado, ou 0 se as credenciais forem inválidas.
+func AuthenticateUser(users store.UserStore, email, password string) (int, error) {
+	return authenticate(users.Credentials, email, password)
+}
+
+// AuthenticateDeletedUser é AuthenticateUser para usuários removidos
+func AuthenticateDeletedUser(users store.UserStore, email, password string) (int, error) {
+	return authenticate(users.DeletedCredentials, email, password)
+}
 
-	query := `SELECT password_hash FROM users WHERE email = $1`
-	err := db.QueryRow(query, email).Scan(&storedHash)
+func authenticate(credentials func(email string) (int, string, error), email, password string) (int, error) {
+	userID, storedHash, err := credentials(email)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return false, nil // Usuário não encontrado
+		if errors.Is(err, store.ErrNotFound) {
+			return 0, nil // Usuário não encontrado
 		}
-		return false, err // Erro ao buscar usuário
+		return 0, err // Erro ao buscar usuário
 	}
 
 	// Verifica se a senha fornecida corresponde ao hash armazenado
 	err = bcrypt.CompareHashAndPassword([]byte(storedHash), []byte(password))
 	if err != nil {
-		return false, nil // Senha incorreta
+		return 0, nil // Senha incorreta
 	}
-	return true, nil // Autenticação bem-sucedida
+	return userID, nil // Autenticação bem-sucedida
 }
 
 // Handler para autenticar um usuário
-func LoginUser(db *sql.DB) http.HandlerFunc {
+func LoginUser(s *store.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
@@ -78,18 +71,39 @@ func LoginUser(db *sql.DB) http.HandlerFunc {
 
 		if email == "" || password == "" {
 			http.Error(w, `{"error": "Todos os campos são obrigatórios"}`, http.StatusBadRequest)
+			return
 		}
 
-		isAuthenticated, err := AuthenticateUser(db, email, password)
+		userID, err := AuthenticateUser(s.Users, email, password)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		if !isAuthenticated {
-			http.Error(w, `{"error": "Usuário ou senha inválidos"}`, http.StatusUnauthorized)
+		if userID == 0 {
+			// Quem removeu a própria conta pode restaurá-la, dentro do prazo,
+			// entrando com restore=1
+			deletedID, err := AuthenticateDeletedUser(s.Users, email, password)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if deletedID == 0 {
+				http.Error(w, `{"error": "Usuário ou senha inválidos"}`, http.StatusUnauthorized)
+				return
+			}
+			if _, ok := restoreUser(s, w, deletedID, r.FormValue("restore") != ""); !ok {
+				return
+			}
+			userID = deletedID
+		}
+
+		sess, err := session.Create(s, userID)
+		if err != nil {
+			http.Error(w, `{"error": "Erro ao criar sessão"}`, http.StatusInternalServerError)
 			return
 		}
+		session.SetCookie(w, r, sess)
 
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("Login bem-sucedido"))
@@ -97,52 +111,100 @@ func LoginUser(db *sql.DB) http.HandlerFunc {
 	}
 }
 
+// Handler para encerrar a sessão do usuário
+func LogoutUser(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if cookie, err := r.Cookie(session.CookieName); err == nil {
+			if err := session.Delete(s, cookie.Value); err != nil {
+				http.Error(w, `{"error": "Erro ao encerrar sessão"}`, http.StatusInternalServerError)
+				return
+			}
+		}
+		session.ClearCookie(w, r)
+
+		json.NewEncoder(w).Encode(map[string]string{"message": "Logout feito com sucesso"})
+	}
+}
+
 // Handler para obter todos os usuários
-func GetUsers(db *sql.DB) http.HandlerFunc {
+func GetUsers(s *store.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
-		rows, err := db.Query("SELECT id, username, email, created_at FROM users")
+		page, ok := pagination.FromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		users, next, err := s.Users.List(page)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		defer rows.Close()
 
-		var users []models.User
-		for rows.Next() {
-			var user models.User
-			if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.CreatedAt); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+		pagination.Write(w, users, next)
+	}
+}
+
+// Handler para obter um usuário específico, com seus números de seguidores e
+// seguidos
+func GetUser(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, `{"error": "Usuário não encontrado"}`, http.StatusNotFound)
+			return
+		}
+
+		user, err := s.Users.Get(id)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				http.Error(w, `{"error": "Usuário não encontrado"}`, http.StatusNotFound)
 				return
 			}
-			users = append(users, user)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		profile := models.Profile{User: *user}
+		if profile.FollowersCount, profile.FollowingCount, err = s.Follows.Counts(id); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
 
-		json.NewEncoder(w).Encode(users)
+		json.NewEncoder(w).Encode(profile)
 	}
 }
 
-// Handler para obter um usuário específico
-func GetUser(db *sql.DB) http.HandlerFunc {
+// Handler da página de perfil (/u/{username}), destino dos links de menção
+func ShowProfile(s *store.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-
-		id := mux.Vars(r)["id"]
+		user, err := s.Users.GetByUsername(mux.Vars(r)["username"])
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				views.RenderError(w, http.StatusNotFound, "Usuário não encontrado")
+				return
+			}
+			views.RenderError(w, http.StatusInternalServerError, "Erro ao carregar o perfil")
+			return
+		}
 
-		var user models.User
-		query := "SELECT id, username, email, created_at FROM users WHERE id = $1"
-		if err := db.QueryRow(query, id).Scan(&user.ID, &user.Username, &user.Email, &user.CreatedAt); err != nil {
-			http.Error(w, err.Error(), http.StatusNotFound)
+		profile := models.Profile{User: *user}
+		if profile.FollowersCount, profile.FollowingCount, err = s.Follows.Counts(user.ID); err != nil {
+			views.RenderError(w, http.StatusInternalServerError, "Erro ao carregar o perfil")
 			return
 		}
 
-		json.NewEncoder(w).Encode(user)
+		views.RenderTemplate(w, "profile.html", profile)
 	}
 }
 
 // Handler para criar um novo usuário
-func CreateUser(db *sql.DB) http.HandlerFunc {
+func CreateUser(s *store.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
@@ -157,7 +219,11 @@ func CreateUser(db *sql.DB) http.HandlerFunc {
 		}
 
 		// Registra o usuário no banco de dados
-		if err := RegisterUser(db, username, email, password); err != nil {
+		if err := RegisterUser(s.Users, username, email, password); err != nil {
+			if errors.Is(err, store.ErrConflict) {
+				http.Error(w, `{"error": "Usuário ou email já cadastrado"}`, http.StatusConflict)
+				return
+			}
 			http.Error(w, `{"error": "Erro ao registrar usuário"}`, http.StatusInternalServerError)
 			return
 		}
@@ -170,19 +236,39 @@ func CreateUser(db *sql.DB) http.HandlerFunc {
 }
 
 // Handler para atualizar um usuário existente
-func UpdateUser(db *sql.DB) http.HandlerFunc {
+func UpdateUser(s *store.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
-		id := mux.Vars(r)["id"]
+		id, ok := authorizeUser(s, w, r)
+		if !ok {
+			return
+		}
+
 		var user models.User
 		if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		query := "UPDATE users SET username = $1, email = $2 WHERE id = $3"
-		if _, err := db.Exec(query, user.Username, user.Email, id); err != nil {
+		// Somente administradores podem alterar o papel de um usuário
+		if user.Role != "" {
+			if !auth.HasRole(auth.UserFromContext(r.Context()), models.RoleAdmin) {
+				auth.Forbidden(w)
+				return
+			}
+			if !models.ValidRole(user.Role) {
+				http.Error(w, `{"error": "Papel inválido"}`, http.StatusBadRequest)
+				return
+			}
+		}
+
+		user.ID = id
+		if err := s.Users.Update(&user); err != nil {
+			if errors.Is(err, store.ErrConflict) {
+				http.Error(w, `{"error": "Usuário ou email já cadastrado"}`, http.StatusConflict)
+				return
+			}
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -193,13 +279,16 @@ func UpdateUser(db *sql.DB) http.HandlerFunc {
 }
 
 // Handler para deletar um usuário
-func DeleteUser(db *sql.DB) http.HandlerFunc {
+func DeleteUser(s *store.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
-		id := mux.Vars(r)["id"]
-		query := "DELETE FROM users WHERE id = $1"
-		if _, err := db.Exec(query, id); err != nil {
+		id, ok := authorizeUser(s, w, r)
+		if !ok {
+			return
+		}
+
+		if err := s.Users.Delete(id); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -208,3 +297,89 @@ func DeleteUser(db *sql.DB) http.HandlerFunc {
 		json.NewEncoder(w).Encode(map[string]string{"message": "Usuário deletado com sucesso"})
 	}
 }
+
+// Handler para um administrador restaurar um usuário removido, dentro de
+// models.RestoreWindow. O próprio usuário, cujas sessões foram encerradas na
+// remoção, restaura a conta entrando com restore=1 (ver LoginUser).
+func RestoreUser(s *store.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if !auth.HasRole(auth.UserFromContext(r.Context()), models.RoleAdmin) {
+			auth.Forbidden(w)
+			return
+		}
+
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, `{"error": "Usuário removido não encontrado"}`, http.StatusNotFound)
+			return
+		}
+
+		user, ok := restoreUser(s, w, id, true)
+		if !ok {
+			return
+		}
+		json.NewEncoder(w).Encode(user)
+	}
+}
+
+// restoreUser restaura o usuário removido se ele ainda estiver no prazo. Sem
+// confirm, apenas responde que a conta foi removida e pode ser restaurada. Em
+// caso negativo a resposta de erro já é escrita e ok é false.
+func restoreUser(s *store.Store, w http.ResponseWriter, id int, confirm bool) (user *models.User, ok bool) {
+	deleted, err := s.Users.Deleted(id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, `{"error": "Usuário removido não encontrado"}`, http.StatusNotFound)
+			return nil, false
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if !models.Restorable(*deleted.DeletedAt) {
+		http.Error(w, `{"error": "Prazo para restauração expirado"}`, http.StatusGone)
+		return nil, false
+	}
+	if !confirm {
+		http.Error(w, `{"error": "Conta removida; entre com restore=1 para restaurá-la"}`, http.StatusForbidden)
+		return nil, false
+	}
+
+	if err := s.Users.Restore(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	if user, err = s.Users.Get(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return user, true
+}
+
+// authorizeUser verifica se o usuário do path existe e se quem faz a requisição
+// pode alterá-lo (o próprio usuário ou um administrador). Em caso negativo a
+// resposta de erro já é escrita e ok é false.
+func authorizeUser(s *store.Store, w http.ResponseWriter, r *http.Request) (id int, ok bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error": "Usuário não encontrado"}`, http.StatusNotFound)
+		return 0, false
+	}
+
+	if _, err := s.Users.Get(id); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, `{"error": "Usuário não encontrado"}`, http.StatusNotFound)
+			return 0, false
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return 0, false
+	}
+
+	if !auth.CanModify(auth.UserFromContext(r.Context()), id, models.RoleAdmin) {
+		auth.Forbidden(w)
+		return 0, false
+	}
+	return id, true
+}
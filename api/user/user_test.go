@@ -0,0 +1,145 @@
+// user_test.go
+package user_test
+
+import (
+	"edsb/api/apitest"
+	"edsb/api/user"
+	"edsb/models"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestDeletedUserRestoresOnLogin(t *testing.T) {
+	srv := apitest.NewServer(t)
+	if err := user.RegisterUser(srv.Store.Users, "ana", "ana@example.com", "senha"); err != nil {
+		t.Fatal(err)
+	}
+	ana, err := srv.Store.Users.GetByUsername("ana")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Store.Users.Delete(ana.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	login := func(password, restore string) int {
+		values := url.Values{"email": {"ana@example.com"}, "password": {password}}
+		if restore != "" {
+			values.Set("restore", restore)
+		}
+		return srv.Anonymous().Form(t, "/users/login", values)
+	}
+
+	if status := login("errada", "1"); status != http.StatusUnauthorized {
+		t.Fatalf("login com senha errada = %d, esperado 401", status)
+	}
+	if status := login("senha", ""); status != http.StatusForbidden {
+		t.Fatalf("login sem restore = %d, esperado 403", status)
+	}
+
+	window := models.RestoreWindow
+	t.Cleanup(func() { models.RestoreWindow = window })
+	models.RestoreWindow = 0
+	if status := login("senha", "1"); status != http.StatusGone {
+		t.Fatalf("login fora do prazo = %d, esperado 410", status)
+	}
+	models.RestoreWindow = time.Hour
+
+	if status := login("senha", "1"); status != http.StatusOK {
+		t.Fatalf("login com restore = %d, esperado 200", status)
+	}
+	if status := srv.Anonymous().Do(t, "GET", fmt.Sprintf("/users/%d", ana.ID), "", nil); status != http.StatusOK {
+		t.Fatalf("GET usuário restaurado = %d, esperado 200", status)
+	}
+	if status := login("senha", ""); status != http.StatusOK {
+		t.Fatalf("login depois de restaurar = %d, esperado 200", status)
+	}
+}
+
+func TestRestoreUserRequiresAdmin(t *testing.T) {
+	srv := apitest.NewServer(t)
+	bia := srv.User(t, "bia", "")
+	admin := srv.User(t, "admin", models.RoleAdmin)
+	carla := srv.User(t, "carla", "")
+	path := fmt.Sprintf("/users/%d", carla.ID)
+
+	if status := carla.Do(t, "DELETE", path, "", nil); status != http.StatusNoContent {
+		t.Fatalf("DELETE = %d, esperado 204", status)
+	}
+	// A remoção encerra as sessões do usuário
+	if status := carla.Do(t, "GET", "/feed", "", nil); status != http.StatusUnauthorized {
+		t.Fatalf("sessão do usuário removido = %d, esperado 401", status)
+	}
+
+	if status := bia.Do(t, "POST", path+"/restore", "", nil); status != http.StatusForbidden {
+		t.Fatalf("restore por outro usuário = %d, esperado 403", status)
+	}
+	var restored models.User
+	if status := admin.Do(t, "POST", path+"/restore", "", &restored); status != http.StatusOK {
+		t.Fatalf("restore pelo administrador = %d, esperado 200", status)
+	}
+	if restored.ID != carla.ID || restored.DeletedAt != nil {
+		t.Fatalf("usuário restaurado = %+v", restored)
+	}
+}
+
+func TestPurgedUserKeepsDiscussions(t *testing.T) {
+	srv := apitest.NewServer(t)
+	ana, bia := srv.User(t, "ana", ""), srv.User(t, "bia", "")
+
+	newPost := func(c *apitest.Client) int {
+		t.Helper()
+		var post models.Post
+		if status := c.Do(t, "POST", "/posts", `{"title": "Título", "content": "Conteúdo"}`, &post); status != http.StatusCreated {
+			t.Fatalf("POST /posts = %d", status)
+		}
+		return post.ID
+	}
+	discussed, lonely, other := newPost(bia), newPost(bia), newPost(ana)
+
+	var comment models.Comment
+	body := fmt.Sprintf(`{"post_id": %d, "content": "Comentário"}`, discussed)
+	if status := ana.Do(t, "POST", "/comments", body, &comment); status != http.StatusCreated {
+		t.Fatalf("POST /comments = %d", status)
+	}
+	for _, path := range []string{
+		fmt.Sprintf("/posts/%d/like", other),
+		fmt.Sprintf("/comments/%d/like", comment.ID),
+		fmt.Sprintf("/posts/%d/like", discussed),
+	} {
+		if status := bia.Do(t, "POST", path, "", nil); status != http.StatusCreated {
+			t.Fatalf("POST %s = %d", path, status)
+		}
+	}
+
+	if err := srv.Store.Users.Delete(bia.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := srv.Store.Users.Purge(time.Now().Add(time.Minute)); err != nil || n != 1 {
+		t.Fatalf("Purge = %d, %v, esperado 1 usuário", n, err)
+	}
+
+	// O post com comentários de outro usuário fica, sem autor e sem conteúdo
+	var post models.Post
+	if status := ana.Do(t, "GET", fmt.Sprintf("/posts/%d", discussed), "", &post); status != http.StatusOK {
+		t.Fatalf("GET post com comentários = %d, esperado 200", status)
+	}
+	if post.UserID != 0 || post.Title != models.RemovedContent || post.Content != models.RemovedContent || post.LikesCount != 0 {
+		t.Fatalf("post mantido = %+v", post)
+	}
+	if status := ana.Do(t, "GET", fmt.Sprintf("/comments/%d", comment.ID), "", &comment); status != http.StatusOK {
+		t.Fatalf("GET comentário = %d, esperado 200", status)
+	}
+	if comment.Content != "Comentário" || comment.LikesCount != 0 {
+		t.Fatalf("comentário = %+v, esperado intacto e sem likes", comment)
+	}
+	if status := ana.Do(t, "GET", fmt.Sprintf("/posts/%d", lonely), "", nil); status != http.StatusNotFound {
+		t.Fatalf("GET post sem comentários = %d, esperado 404", status)
+	}
+	if status := ana.Do(t, "GET", fmt.Sprintf("/posts/%d", other), "", &post); status != http.StatusOK || post.LikesCount != 0 {
+		t.Fatalf("post curtido = %d %+v, esperado likes_count 0", status, post)
+	}
+}
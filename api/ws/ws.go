@@ -0,0 +1,303 @@
+// ws.go
+package ws
+
+import (
+	"edsb/api/auth"
+	"edsb/events"
+	"edsb/models"
+	"edsb/store"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var (
+	// QueueSize é quantas respostas a comandos cada conexão acumula antes de
+	// ser encerrada por não consumi-las. Os eventos têm a própria fila, de
+	// events.BufferSize.
+	QueueSize = 16
+	// MaxSubscriptions é o número máximo de posts e conversas assinados por
+	// conexão
+	MaxSubscriptions = 100
+	// MaxMessageSize é o tamanho máximo, em bytes, de um comando do cliente
+	MaxMessageSize int64 = 4096
+	// WriteWait é o tempo máximo para escrever uma mensagem no cliente
+	WriteWait = 10 * time.Second
+	// PongWait é quanto tempo se espera pelo pong antes de considerar a
+	// conexão morta; os pings são enviados a cada 9/10 desse tempo
+	PongWait = 60 * time.Second
+)
+
+// O Upgrader padrão recusa handshakes de outras origens, o que impede que
+// outro site abra a conexão com o cookie de sessão do usuário
+var upgrader = websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 1024}
+
+// Command é uma mensagem enviada pelo cliente:
+//
+//	{"type": "subscribe", "posts": [1, 2], "conversations": [3]}
+//	{"type": "unsubscribe", "posts": [1]}
+//	{"type": "ping"}
+type Command struct {
+	Type          string `json:"type"`
+	Posts         []int  `json:"posts"`
+	Conversations []int  `json:"conversations"`
+}
+
+// Message é uma mensagem enviada ao cliente. Type é "event" para os eventos do
+// Hub (com Event e Data), "subscribed" ou "unsubscribed" em resposta aos
+// comandos (com as assinaturas atuais), "pong" ou "error".
+type Message struct {
+	Type          string         `json:"type"`
+	Event         string         `json:"event,omitempty"`
+	Data          any            `json:"data,omitempty"`
+	Subscriptions *Subscriptions `json:"subscriptions,omitempty"`
+	Error         string         `json:"error,omitempty"`
+}
+
+// Subscriptions são os posts e conversas assinados por uma conexão
+type Subscriptions struct {
+	Posts         []int `json:"posts"`
+	Conversations []int `json:"conversations"`
+}
+
+// Handler do gateway WebSocket (GET /ws). A conexão recebe as notificações do
+// usuário autenticado e os eventos dos posts e conversas que assinar. Cada
+// conexão tem filas limitadas: se o cliente não acompanhar os eventos, a
+// conexão é encerrada com o código 1013 e ele deve se reconectar e recarregar
+// o que perdeu.
+func Gateway(s *store.Store, hub *events.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return // o Upgrader já respondeu com o erro
+		}
+
+		c := &client{
+			conn:    conn,
+			store:   s,
+			user:    auth.UserFromContext(r.Context()),
+			replies: make(chan Message, QueueSize),
+			done:    make(chan struct{}),
+		}
+		// Sem posts assinados, nenhum evento de post é entregue
+		c.filter = events.Filter{UserID: c.user.ID, PostIDs: map[int]bool{}, ConversationIDs: map[int]bool{}}
+		c.sub = hub.Subscribe(c.filter)
+		defer c.sub.Close()
+
+		go c.writeLoop()
+		c.readLoop()
+	}
+}
+
+// client é uma conexão aberta. Somente readLoop altera o filtro e somente
+// writeLoop escreve mensagens de dados na conexão.
+type client struct {
+	conn    *websocket.Conn
+	store   *store.Store
+	user    *models.User
+	sub     *events.Subscription
+	filter  events.Filter
+	replies chan Message
+	done    chan struct{}
+}
+
+// readLoop lê e executa os comandos do cliente até a conexão ser encerrada
+func (c *client) readLoop() {
+	defer close(c.done)
+
+	c.conn.SetReadLimit(MaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(PongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(PongWait))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				log.Printf("Erro na conexão WebSocket do usuário %d: %v", c.user.ID, err)
+			}
+			return
+		}
+
+		var cmd Command
+		if err := json.Unmarshal(data, &cmd); err != nil {
+			c.reply(Message{Type: "error", Error: "Comando inválido"})
+			continue
+		}
+		switch cmd.Type {
+		case "subscribe":
+			c.subscribe(cmd)
+		case "unsubscribe":
+			c.unsubscribe(cmd)
+		case "ping":
+			c.reply(Message{Type: "pong"})
+		default:
+			c.reply(Message{Type: "error", Error: "Comando desconhecido"})
+		}
+	}
+}
+
+// subscribe assina os posts e conversas do comando. Se algum não existir (ou,
+// no caso das conversas, o usuário não participar dela) nada é assinado. O
+// limite é conferido antes, para que um comando grande demais não chegue a
+// consultar o banco, e só os ids ainda não assinados são consultados, uma vez
+// cada.
+func (c *client) subscribe(cmd Command) {
+	filter := c.copyFilter()
+	var posts, conversations []int
+	for _, id := range cmd.Posts {
+		if !filter.PostIDs[id] {
+			filter.PostIDs[id] = true
+			posts = append(posts, id)
+		}
+	}
+	for _, id := range cmd.Conversations {
+		if !filter.ConversationIDs[id] {
+			filter.ConversationIDs[id] = true
+			conversations = append(conversations, id)
+		}
+	}
+	if len(filter.PostIDs)+len(filter.ConversationIDs) > MaxSubscriptions {
+		c.reply(Message{Type: "error", Error: "Limite de assinaturas atingido"})
+		return
+	}
+
+	for _, id := range posts {
+		if _, err := c.store.Posts.Get(id); err != nil {
+			c.lookupError(err, fmt.Sprintf("Post %d não encontrado", id))
+			return
+		}
+	}
+	for _, id := range conversations {
+		if _, err := c.store.Conversations.Get(id, c.user.ID); err != nil {
+			c.lookupError(err, fmt.Sprintf("Conversa %d não encontrada", id))
+			return
+		}
+	}
+
+	c.setFilter(filter)
+	c.reply(Message{Type: "subscribed", Subscriptions: c.subscriptions()})
+}
+
+// unsubscribe cancela as assinaturas do comando; ids não assinados são ignorados
+func (c *client) unsubscribe(cmd Command) {
+	filter := c.copyFilter()
+	for _, id := range cmd.Posts {
+		delete(filter.PostIDs, id)
+	}
+	for _, id := range cmd.Conversations {
+		delete(filter.ConversationIDs, id)
+	}
+
+	c.setFilter(filter)
+	c.reply(Message{Type: "unsubscribed", Subscriptions: c.subscriptions()})
+}
+
+// lookupError responde a um erro ao validar uma assinatura
+func (c *client) lookupError(err error, notFound string) {
+	if errors.Is(err, store.ErrNotFound) {
+		c.reply(Message{Type: "error", Error: notFound})
+		return
+	}
+	log.Printf("Erro ao validar assinatura do usuário %d: %v", c.user.ID, err)
+	c.reply(Message{Type: "error", Error: "Erro ao assinar"})
+}
+
+// copyFilter copia o filtro atual. O Hub lê os mapas do filtro ao publicar,
+// então eles nunca são alterados depois de entregues a SetFilter.
+func (c *client) copyFilter() events.Filter {
+	f := events.Filter{UserID: c.filter.UserID, PostIDs: map[int]bool{}, ConversationIDs: map[int]bool{}}
+	for id := range c.filter.PostIDs {
+		f.PostIDs[id] = true
+	}
+	for id := range c.filter.ConversationIDs {
+		f.ConversationIDs[id] = true
+	}
+	return f
+}
+
+func (c *client) setFilter(f events.Filter) {
+	c.filter = f
+	c.sub.SetFilter(f)
+}
+
+// subscriptions lista, em ordem, os posts e conversas assinados
+func (c *client) subscriptions() *Subscriptions {
+	subs := &Subscriptions{Posts: []int{}, Conversations: []int{}}
+	for id := range c.filter.PostIDs {
+		subs.Posts = append(subs.Posts, id)
+	}
+	for id := range c.filter.ConversationIDs {
+		subs.Conversations = append(subs.Conversations, id)
+	}
+	sort.Ints(subs.Posts)
+	sort.Ints(subs.Conversations)
+	return subs
+}
+
+// reply enfileira uma resposta sem bloquear. Com a fila cheia o cliente não
+// está lendo o que envia e a conexão é encerrada.
+func (c *client) reply(msg Message) {
+	select {
+	case c.replies <- msg:
+	default:
+		c.close(websocket.CloseTryAgainLater, "Fila de mensagens cheia")
+	}
+}
+
+// writeLoop envia as respostas, os eventos e os pings até a conexão ser
+// encerrada
+func (c *client) writeLoop() {
+	ping := time.NewTicker(PongWait * 9 / 10)
+	defer ping.Stop()
+
+	for {
+		var msg Message
+		select {
+		case <-c.done:
+			c.close(websocket.CloseNormalClosure, "")
+			return
+		case <-ping.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(WriteWait)); err != nil {
+				c.conn.Close()
+				return
+			}
+			continue
+		case msg = <-c.replies:
+		case e, ok := <-c.sub.C:
+			if !ok {
+				c.close(websocket.CloseNormalClosure, "")
+				return
+			}
+			msg = Message{Type: "event", Event: e.Name, Data: e.Data}
+		}
+
+		// O Hub descarta os eventos quando a fila da assinatura enche. Em vez
+		// de entregar um stream com lacunas, a conexão é encerrada.
+		if c.sub.Dropped() > 0 {
+			c.close(websocket.CloseTryAgainLater, "Fila de eventos cheia")
+			return
+		}
+
+		c.conn.SetWriteDeadline(time.Now().Add(WriteWait))
+		if err := c.conn.WriteJSON(msg); err != nil {
+			c.conn.Close()
+			return
+		}
+	}
+}
+
+// close envia a mensagem de encerramento e fecha a conexão. Pode ser chamado
+// de qualquer goroutine.
+func (c *client) close(code int, reason string) {
+	msg := websocket.FormatCloseMessage(code, reason)
+	c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(WriteWait))
+	c.conn.Close()
+}
@@ -0,0 +1,214 @@
+// ws_test.go
+package ws_test
+
+import (
+	"edsb/api/apitest"
+	"edsb/api/ws"
+	"edsb/events"
+	"edsb/models"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// dial abre o gateway com o cookie de sessão informado
+func dial(t *testing.T, srv *apitest.Server, cookie string) *websocket.Conn {
+	t.Helper()
+	header := http.Header{}
+	if cookie != "" {
+		header.Set("Cookie", cookie)
+	}
+	conn, res, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", header)
+	if err != nil {
+		status := 0
+		if res != nil {
+			status = res.StatusCode
+		}
+		t.Fatalf("Dial: %v (status %d)", err, status)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func send(t *testing.T, conn *websocket.Conn, cmd ws.Command) {
+	t.Helper()
+	if err := conn.WriteJSON(cmd); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func read(t *testing.T, conn *websocket.Conn) ws.Message {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var msg ws.Message
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("ReadJSON: %v", err)
+	}
+	return msg
+}
+
+func TestGatewayRequiresSession(t *testing.T) {
+	srv := apitest.NewServer(t)
+
+	_, res, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+	if err == nil {
+		t.Fatal("conexão sem sessão foi aceita")
+	}
+	if res == nil || res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("resposta = %v, esperado 401", res)
+	}
+}
+
+func TestGatewayDeliversSubscribedEvents(t *testing.T) {
+	srv := apitest.NewServer(t)
+	ana, bia := srv.User(t, "ana", ""), srv.User(t, "bia", "")
+
+	post := models.Post{UserID: ana.ID, Title: "Título", Content: "Conteúdo"}
+	if err := srv.Store.Posts.Create(&post); err != nil {
+		t.Fatal(err)
+	}
+	conv := models.Conversation{CreatedBy: ana.ID}
+	if err := srv.Store.Conversations.Create(&conv, []int{ana.ID, bia.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	conn := dial(t, srv, ana.Cookie)
+	send(t, conn, ws.Command{Type: "subscribe", Posts: []int{post.ID}, Conversations: []int{conv.ID}})
+	msg := read(t, conn)
+	if msg.Type != "subscribed" || fmt.Sprint(msg.Subscriptions.Posts) != fmt.Sprint([]int{post.ID}) ||
+		fmt.Sprint(msg.Subscriptions.Conversations) != fmt.Sprint([]int{conv.ID}) {
+		t.Fatalf("resposta ao subscribe = %+v", msg)
+	}
+
+	// Eventos de posts não assinados não chegam
+	srv.Hub.Publish(events.Event{Name: "likes-posts-999", PostID: 999, Data: 1})
+	srv.Hub.Publish(events.Event{Name: fmt.Sprintf("likes-posts-%d", post.ID), PostID: post.ID, Data: 2})
+	if msg := read(t, conn); msg.Type != "event" || msg.Event != fmt.Sprintf("likes-posts-%d", post.ID) {
+		t.Fatalf("evento = %+v, esperado o do post assinado", msg)
+	}
+
+	// Uma mensagem enviada pela outra participante chega pela conversa
+	path := fmt.Sprintf("/conversations/%d/messages", conv.ID)
+	if status := bia.Do(t, "POST", path, `{"content": "oi"}`, nil); status != http.StatusCreated {
+		t.Fatalf("POST mensagem = %d", status)
+	}
+	msg = read(t, conn)
+	if msg.Type != "event" || msg.Event != fmt.Sprintf("messages-conversations-%d", conv.ID) {
+		t.Fatalf("evento = %+v, esperado a mensagem da conversa", msg)
+	}
+	if data, ok := msg.Data.(map[string]any); !ok || data["content"] != "oi" {
+		t.Fatalf("dados do evento = %#v", msg.Data)
+	}
+	if msg := read(t, conn); msg.Type != "event" || msg.Event != fmt.Sprintf("unread-conversations-%d", conv.ID) {
+		t.Fatalf("evento = %+v, esperado o aviso de mensagem nova", msg)
+	}
+
+	// Depois do unsubscribe os eventos do post deixam de chegar
+	send(t, conn, ws.Command{Type: "unsubscribe", Posts: []int{post.ID}})
+	if msg := read(t, conn); msg.Type != "unsubscribed" || len(msg.Subscriptions.Posts) != 0 {
+		t.Fatalf("resposta ao unsubscribe = %+v", msg)
+	}
+	srv.Hub.Publish(events.Event{Name: "likes-posts", PostID: post.ID, Data: 3})
+	send(t, conn, ws.Command{Type: "ping"})
+	if msg := read(t, conn); msg.Type != "pong" {
+		t.Fatalf("resposta ao ping = %+v, esperado pong (sem o evento do post)", msg)
+	}
+}
+
+func TestGatewayRejectsOtherUsersConversation(t *testing.T) {
+	srv := apitest.NewServer(t)
+	ana, bia, carla := srv.User(t, "ana", ""), srv.User(t, "bia", ""), srv.User(t, "carla", "")
+
+	post := models.Post{UserID: ana.ID, Title: "Título", Content: "Conteúdo"}
+	if err := srv.Store.Posts.Create(&post); err != nil {
+		t.Fatal(err)
+	}
+	conv := models.Conversation{CreatedBy: ana.ID}
+	if err := srv.Store.Conversations.Create(&conv, []int{ana.ID, bia.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	conn := dial(t, srv, carla.Cookie)
+	send(t, conn, ws.Command{Type: "subscribe", Posts: []int{post.ID}, Conversations: []int{conv.ID}})
+	msg := read(t, conn)
+	if msg.Type != "error" || msg.Error != fmt.Sprintf("Conversa %d não encontrada", conv.ID) {
+		t.Fatalf("resposta = %+v, esperado erro de conversa não encontrada", msg)
+	}
+
+	// Nada do comando recusado foi assinado, nem o post
+	srv.Hub.Publish(events.Event{Name: "likes-posts", PostID: post.ID, Data: 1})
+	send(t, conn, ws.Command{Type: "subscribe"})
+	msg = read(t, conn)
+	if msg.Type != "subscribed" || len(msg.Subscriptions.Posts) != 0 || len(msg.Subscriptions.Conversations) != 0 {
+		t.Fatalf("assinaturas = %+v, esperado nenhuma", msg)
+	}
+}
+
+func TestGatewayClosesSlowClient(t *testing.T) {
+	srv := apitest.NewServer(t)
+	ana := srv.User(t, "ana", "")
+
+	post := models.Post{UserID: ana.ID, Title: "Título", Content: "Conteúdo"}
+	if err := srv.Store.Posts.Create(&post); err != nil {
+		t.Fatal(err)
+	}
+
+	conn := dial(t, srv, ana.Cookie)
+	send(t, conn, ws.Command{Type: "subscribe", Posts: []int{post.ID}})
+	if msg := read(t, conn); msg.Type != "subscribed" {
+		t.Fatalf("resposta ao subscribe = %+v", msg)
+	}
+
+	// Eventos grandes publicados de uma vez enchem a fila da assinatura
+	// antes que o gateway consiga escrevê-los
+	payload := strings.Repeat("x", 256<<10)
+	for i := 0; i < 4*events.BufferSize; i++ {
+		srv.Hub.Publish(events.Event{Name: "big", PostID: post.ID, Data: payload})
+	}
+
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		if !websocket.IsCloseError(err, websocket.CloseTryAgainLater) {
+			t.Fatalf("erro = %v, esperado fechamento com o código 1013", err)
+		}
+		break
+	}
+}
+
+func TestGatewaySubscriptionLimit(t *testing.T) {
+	srv := apitest.NewServer(t)
+	ana := srv.User(t, "ana", "")
+	post := models.Post{UserID: ana.ID, Title: "Título", Content: "Conteúdo"}
+	if err := srv.Store.Posts.Create(&post); err != nil {
+		t.Fatal(err)
+	}
+	conn := dial(t, srv, ana.Cookie)
+
+	// O limite é conferido antes de procurar os posts, que nem existem
+	ids := make([]int, ws.MaxSubscriptions+1)
+	for i := range ids {
+		ids[i] = 1000 + i
+	}
+	send(t, conn, ws.Command{Type: "subscribe", Posts: ids})
+	if msg := read(t, conn); msg.Type != "error" || msg.Error != "Limite de assinaturas atingido" {
+		t.Fatalf("resposta = %+v, esperado erro de limite", msg)
+	}
+
+	// Ids repetidos contam uma vez só
+	repeated := make([]int, 2*ws.MaxSubscriptions)
+	for i := range repeated {
+		repeated[i] = post.ID
+	}
+	send(t, conn, ws.Command{Type: "subscribe", Posts: repeated})
+	if msg := read(t, conn); msg.Type != "subscribed" || len(msg.Subscriptions.Posts) != 1 {
+		t.Fatalf("resposta = %+v, esperado o post assinado", msg)
+	}
+}
@@ -0,0 +1,179 @@
+// Package assets serve os arquivos estáticos com URLs versionadas pelo
+// conteúdo (styles.3fa9c1b2d4.css), cache de longa duração e variantes
+// pré-comprimidas.
+package assets
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// Prefix é o caminho sob o qual os arquivos são servidos
+const Prefix = "/static/"
+
+// hashLen é quantos caracteres do SHA-256 entram na URL versionada
+const hashLen = 10
+
+// compressible são as extensões para as quais vale gerar uma variante gzip
+var compressible = map[string]bool{
+	".css": true, ".js": true, ".svg": true, ".json": true, ".txt": true, ".map": true,
+}
+
+// asset é um arquivo carregado em memória com suas variantes comprimidas
+type asset struct {
+	name    string // caminho lógico, ex.: vendor/htmx.min.js
+	hash    string
+	content []byte
+	gzip    []byte // nil se não houver variante gzip
+	brotli  []byte // nil se não houver arquivo .br correspondente
+}
+
+// Assets é o conjunto de arquivos estáticos servidos pela aplicação
+type Assets struct {
+	byName   map[string]*asset // caminho lógico -> arquivo
+	byURL    map[string]*asset // caminho versionado -> arquivo
+	loadedAt time.Time
+}
+
+// New carrega todos os arquivos de fsys. Arquivos .gz e .br são tratados como
+// variantes pré-comprimidas do arquivo de mesmo nome sem a extensão; para os
+// arquivos de texto sem .gz a variante gzip é gerada aqui.
+func New(fsys fs.FS) (*Assets, error) {
+	a := &Assets{byName: map[string]*asset{}, byURL: map[string]*asset{}, loadedAt: time.Now()}
+	variants := map[string][]byte{}
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		// O código Go que embute o diretório não é um arquivo estático
+		if path.Ext(name) == ".go" {
+			return nil
+		}
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		if ext := path.Ext(name); ext == ".gz" || ext == ".br" {
+			variants[name] = content
+			return nil
+		}
+
+		sum := sha256.Sum256(content)
+		a.byName[name] = &asset{name: name, hash: hex.EncodeToString(sum[:])[:hashLen], content: content}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for name, f := range a.byName {
+		f.brotli = variants[name+".br"]
+		f.gzip = variants[name+".gz"]
+		if f.gzip == nil && compressible[path.Ext(name)] {
+			if f.gzip, err = compress(f.content); err != nil {
+				return nil, err
+			}
+		}
+		a.byURL[fingerprint(name, f.hash)] = f
+	}
+	return a, nil
+}
+
+// Has indica se o arquivo existe
+func (a *Assets) Has(name string) bool {
+	_, ok := a.byName[strings.TrimPrefix(name, "/")]
+	return ok
+}
+
+// URL retorna a URL versionada do arquivo, que pode ser guardada em cache
+// indefinidamente. Arquivos desconhecidos recebem a URL sem versão.
+func (a *Assets) URL(name string) string {
+	name = strings.TrimPrefix(name, "/")
+	if f, ok := a.byName[name]; ok {
+		return Prefix + fingerprint(name, f.hash)
+	}
+	return Prefix + name
+}
+
+// ServeHTTP serve os arquivos sob Prefix. URLs versionadas recebem cache de
+// um ano; as demais (ex.: referências relativas dentro de um CSS) são
+// revalidadas pelo ETag.
+func (a *Assets) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, Prefix)
+
+	f, versioned := a.byURL[name]
+	if !versioned {
+		if f = a.byName[name]; f == nil {
+			http.NotFound(w, r)
+			return
+		}
+	}
+
+	h := w.Header()
+	if versioned {
+		h.Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		h.Set("Cache-Control", "public, no-cache")
+	}
+	if ctype := mime.TypeByExtension(path.Ext(f.name)); ctype != "" {
+		h.Set("Content-Type", ctype)
+	}
+
+	content, etag := f.content, `"`+f.hash+`"`
+	if f.gzip != nil || f.brotli != nil {
+		h.Add("Vary", "Accept-Encoding")
+		switch accepts := r.Header.Get("Accept-Encoding"); {
+		case f.brotli != nil && acceptsEncoding(accepts, "br"):
+			content, etag = f.brotli, `"`+f.hash+`-br"`
+			h.Set("Content-Encoding", "br")
+		case f.gzip != nil && acceptsEncoding(accepts, "gzip"):
+			content, etag = f.gzip, `"`+f.hash+`-gz"`
+			h.Set("Content-Encoding", "gzip")
+		}
+	}
+	h.Set("ETag", etag)
+
+	http.ServeContent(w, r, f.name, a.loadedAt, bytes.NewReader(content))
+}
+
+// fingerprint insere o hash antes da extensão: css/app.css -> css/app.<hash>.css
+func fingerprint(name, hash string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+// acceptsEncoding verifica se o cabeçalho Accept-Encoding aceita a codificação
+func acceptsEncoding(header, encoding string) bool {
+	for _, part := range strings.Split(header, ",") {
+		value, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.TrimSpace(value) != encoding {
+			continue
+		}
+		return strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0"
+	}
+	return false
+}
+
+func compress(content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write(content); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,194 @@
+// diff.go
+package diff
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Op é o tipo de um trecho da comparação
+type Op string
+
+const (
+	Equal  Op = "equal"  // presente nos dois textos
+	Insert Op = "insert" // presente somente no novo
+	Delete Op = "delete" // presente somente no antigo
+)
+
+// Change é um trecho da comparação. Juntando os trechos Equal e Delete
+// obtém-se o texto antigo; juntando Equal e Insert, o novo.
+type Change struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// MaxCells limita o trabalho da maior subsequência comum (tokens diferentes
+// do texto antigo × do novo, fora o prefixo e o sufixo comuns). Acima disso o
+// trecho diferente é tratado como removido e inserido por inteiro. A memória
+// usada é linear no tamanho dos textos, qualquer que seja o limite.
+var MaxCells = 4_000_000
+
+// Lines compara os textos linha a linha
+func Lines(a, b string) []Change {
+	return Tokens(SplitLines(a), SplitLines(b))
+}
+
+// Words compara os textos palavra a palavra
+func Words(a, b string) []Change {
+	return Tokens(SplitWords(a), SplitWords(b))
+}
+
+// SplitLines divide o texto em linhas, com cada "\n" num token próprio. Assim
+// a última linha é igual à mesma linha seguida de outras.
+func SplitLines(s string) []string {
+	var lines []string
+	for s != "" {
+		i := strings.IndexByte(s, '\n')
+		if i < 0 {
+			lines = append(lines, s)
+			break
+		}
+		if i > 0 {
+			lines = append(lines, s[:i])
+		}
+		lines = append(lines, "\n")
+		s = s[i+1:]
+	}
+	return lines
+}
+
+// SplitWords divide o texto em palavras e sequências de espaços, de modo que
+// os espaços também entram na comparação
+func SplitWords(s string) []string {
+	var words []string
+	start, space := 0, false
+	for i, r := range s {
+		isSpace := unicode.IsSpace(r)
+		if i > 0 && isSpace != space {
+			words = append(words, s[start:i])
+			start = i
+		}
+		space = isSpace
+	}
+	if start < len(s) {
+		words = append(words, s[start:])
+	}
+	return words
+}
+
+// Tokens compara duas sequências de tokens pela maior subsequência comum.
+// Trechos consecutivos da mesma operação são unidos e, num trecho alterado,
+// as remoções vêm antes das inserções.
+func Tokens(a, b []string) []Change {
+	changes := []Change{}
+
+	// O prefixo e o sufixo comuns ficam fora da tabela
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	changes = add(changes, Equal, a[:prefix]...)
+	changes = middle(changes, a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	return add(changes, Equal, a[len(a)-suffix:]...)
+}
+
+// middle compara os trechos diferentes de a e b
+func middle(changes []Change, a, b []string) []Change {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 || (n+1)*(m+1) > MaxCells {
+		changes = add(changes, Delete, a...)
+		return add(changes, Insert, b...)
+	}
+
+	// Entre dois tokens iguais, as remoções vêm antes das inserções
+	i, j := 0, 0
+	for _, p := range lcs(a, b, 0, 0, nil) {
+		changes = add(changes, Delete, a[i:p.a]...)
+		changes = add(changes, Insert, b[j:p.b]...)
+		changes = add(changes, Equal, a[p.a])
+		i, j = p.a+1, p.b+1
+	}
+	changes = add(changes, Delete, a[i:]...)
+	return add(changes, Insert, b[j:]...)
+}
+
+// match é um par de tokens iguais, pelas posições em a e em b
+type match struct{ a, b int }
+
+// lcs acrescenta a matches, em ordem, os pares de uma maior subsequência
+// comum de a e b (deslocados de aOff e bOff). Usa o algoritmo de Hirschberg:
+// divide a ao meio e acha onde dividir b somando as linhas finais das tabelas
+// da metade de cima e, de trás para frente, da de baixo, de modo que só uma
+// linha de cada tabela fica na memória.
+func lcs(a, b []string, aOff, bOff int, matches []match) []match {
+	if len(a) == 0 || len(b) == 0 {
+		return matches
+	}
+	if len(a) == 1 {
+		for j, t := range b {
+			if t == a[0] {
+				return append(matches, match{aOff, bOff + j})
+			}
+		}
+		return matches
+	}
+
+	mid := len(a) / 2
+	top := lastRow(a[:mid], b, false)
+	bottom := lastRow(a[mid:], b, true)
+
+	// bottom[len(b)-k] é o tamanho da maior subsequência comum de a[mid:] e b[k:]
+	split, best := 0, int32(-1)
+	for k := 0; k <= len(b); k++ {
+		if l := top[k] + bottom[len(b)-k]; l > best {
+			split, best = k, l
+		}
+	}
+	matches = lcs(a[:mid], b[:split], aOff, bOff, matches)
+	return lcs(a[mid:], b[split:], aOff+mid, bOff+split, matches)
+}
+
+// lastRow retorna a última linha da tabela da maior subsequência comum: row[j]
+// é o tamanho da maior subsequência comum de a e b[:j] ou, com reverse, de a e
+// dos últimos j tokens de b
+func lastRow(a, b []string, reverse bool) []int32 {
+	prev := make([]int32, len(b)+1)
+	cur := make([]int32, len(b)+1)
+	for i := range a {
+		x := a[i]
+		if reverse {
+			x = a[len(a)-1-i]
+		}
+		for j := 1; j <= len(b); j++ {
+			y := b[j-1]
+			if reverse {
+				y = b[len(b)-j]
+			}
+			if x == y {
+				cur[j] = prev[j-1] + 1
+			} else {
+				cur[j] = max(prev[j], cur[j-1])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
+
+// add acrescenta os tokens à comparação, unindo-os ao último trecho se ele for
+// da mesma operação
+func add(changes []Change, op Op, tokens ...string) []Change {
+	for _, t := range tokens {
+		if last := len(changes) - 1; last >= 0 && changes[last].Op == op {
+			changes[last].Text += t
+			continue
+		}
+		changes = append(changes, Change{Op: op, Text: t})
+	}
+	return changes
+}
@@ -0,0 +1,96 @@
+// diff_test.go
+package diff
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// rebuild junta os trechos de volta no texto antigo e no novo
+func rebuild(changes []Change) (a, b string) {
+	var old, new strings.Builder
+	for _, c := range changes {
+		if c.Op != Insert {
+			old.WriteString(c.Text)
+		}
+		if c.Op != Delete {
+			new.WriteString(c.Text)
+		}
+	}
+	return old.String(), new.String()
+}
+
+// equalTokens conta os tokens dos trechos Equal
+func equalTokens(changes []Change) int {
+	n := 0
+	for _, c := range changes {
+		if c.Op == Equal {
+			n += len(SplitLines(c.Text))
+		}
+	}
+	return n
+}
+
+// lcsLen calcula com a tabela inteira o tamanho da maior subsequência comum
+func lcsLen(a, b []string) int {
+	table := make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+	return table[0][0]
+}
+
+func randomText(r *rand.Rand) string {
+	lines := make([]string, r.Intn(30))
+	for i := range lines {
+		lines[i] = string(rune('a' + r.Intn(4)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestLinesIsMinimal(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		a, b := randomText(r), randomText(r)
+		changes := Lines(a, b)
+		if oldText, newText := rebuild(changes); oldText != a || newText != b {
+			t.Fatalf("Lines(%q, %q) não reconstrói os textos: %+v", a, b, changes)
+		}
+		if got, want := equalTokens(changes), lcsLen(SplitLines(a), SplitLines(b)); got != want {
+			t.Fatalf("Lines(%q, %q) mantém %d linhas, esperado %d: %+v", a, b, got, want, changes)
+		}
+		for j := 1; j < len(changes); j++ {
+			if changes[j-1].Op == changes[j].Op || changes[j-1].Op == Insert && changes[j].Op == Delete {
+				t.Fatalf("Lines(%q, %q) trechos fora de ordem: %+v", a, b, changes)
+			}
+		}
+	}
+}
+
+func TestTokensOverMaxCells(t *testing.T) {
+	cells := MaxCells
+	t.Cleanup(func() { MaxCells = cells })
+	MaxCells = 10
+
+	// O trecho diferente tem 3 × 4 tokens: a tabela passaria do limite
+	changes := Tokens(strings.Split("abcde", ""), strings.Split("acbdfe", ""))
+	want := []Change{{Equal, "a"}, {Delete, "bcd"}, {Insert, "cbdf"}, {Equal, "e"}}
+	if len(changes) != len(want) {
+		t.Fatalf("Tokens = %+v, esperado %+v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Fatalf("Tokens = %+v, esperado %+v", changes, want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// events.go
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// BufferSize é quantos eventos cada assinatura acumula antes de começar a
+// descartar os novos. Um cliente lento perde eventos, mas nunca atrasa quem
+// publica nem os demais clientes.
+const BufferSize = 32
+
+// Event é uma atualização publicada no Hub
+type Event struct {
+	Name           string // nome do evento (ex.: "likes-posts-12"), usado pelo htmx em sse-swap
+	UserID         int    // destinatário; 0 para eventos públicos
+	PostID         int    // post a que o evento se refere, usado no filtro das assinaturas; 0 se nenhum
+	ConversationID int    // conversa a que o evento se refere; só vai a quem a assinou. 0 se nenhuma
+	Data           any    // conteúdo do evento, enviado em JSON
+	HTML           string // fragmento enviado aos clientes que pedem HTML; vazio para usar Data
+}
+
+// Filter define quais eventos uma assinatura recebe
+type Filter struct {
+	// UserID é o usuário da assinatura (0 se anônima). Eventos com
+	// destinatário só são entregues às assinaturas desse usuário.
+	UserID int
+	// PostIDs restringe os eventos de posts aos posts informados; nil para
+	// todos (um mapa vazio não recebe nenhum)
+	PostIDs map[int]bool
+	// ConversationIDs são as conversas cujos eventos a assinatura recebe
+	ConversationIDs map[int]bool
+}
+
+// Match indica se o evento deve ser entregue a uma assinatura com este filtro
+func (f Filter) Match(e Event) bool {
+	if e.UserID != 0 && e.UserID != f.UserID {
+		return false
+	}
+	if e.PostID != 0 && f.PostIDs != nil && !f.PostIDs[e.PostID] {
+		return false
+	}
+	if e.ConversationID != 0 && !f.ConversationIDs[e.ConversationID] {
+		return false
+	}
+	return true
+}
+
+// Hub distribui os eventos publicados entre as assinaturas, dentro do
+// próprio processo
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+// NewHub cria um Hub sem assinaturas
+func NewHub() *Hub {
+	return &Hub{subs: map[*Subscription]struct{}{}}
+}
+
+// Subscription recebe em C os eventos que satisfazem seu filtro até ser
+// encerrada com Close
+type Subscription struct {
+	C <-chan Event
+
+	ch      chan Event
+	filter  Filter
+	hub     *Hub
+	once    sync.Once
+	dropped atomic.Int64
+}
+
+// Subscribe cria uma assinatura. Quem assina deve chamar Close ao terminar.
+func (h *Hub) Subscribe(f Filter) *Subscription {
+	ch := make(chan Event, BufferSize)
+	sub := &Subscription{C: ch, ch: ch, filter: f, hub: h}
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+// SetFilter troca o filtro da assinatura; os eventos publicados a partir daí
+// já usam o novo filtro
+func (s *Subscription) SetFilter(f Filter) {
+	s.hub.mu.Lock()
+	s.filter = f
+	s.hub.mu.Unlock()
+}
+
+// Close encerra a assinatura e fecha C. Pode ser chamado mais de uma vez.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.hub.mu.Lock()
+		delete(s.hub.subs, s)
+		s.hub.mu.Unlock()
+		close(s.ch)
+	})
+}
+
+// Dropped é quantos eventos foram descartados porque C estava cheio
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Publish entrega o evento às assinaturas interessadas sem bloquear
+func (h *Hub) Publish(e Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.subs {
+		if !sub.filter.Match(e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// Len é o número de assinaturas abertas
+func (h *Hub) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subs)
+}
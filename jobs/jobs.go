@@ -0,0 +1,44 @@
+// jobs.go
+package jobs
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// Every executa fn em segundo plano a cada intervalo, registrando os erros no
+// log. Um intervalo menor ou igual a zero desativa o job.
+func Every(name string, interval time.Duration, fn func() error) {
+	if interval <= 0 {
+		log.Printf("Job %s desativado.", name)
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := fn(); err != nil {
+				log.Printf("Erro no job %s: %v", name, err)
+			}
+		}
+	}()
+	log.Printf("Job %s agendado a cada %s.", name, interval)
+}
+
+// IntervalFromEnv lê uma duração (ex.: "1h", "30m", "0" para desativar) da
+// variável de ambiente informada, usando def quando ela não está definida
+func IntervalFromEnv(name string, def time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s inválido: %w", name, err)
+	}
+	return d, nil
+}
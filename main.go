@@ -3,16 +3,26 @@ package main
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"edsb/api/comment"
-	"edsb/api/like"
-	"edsb/api/post"
 	"edsb/api/routes"
-	"edsb/api/user"
+	"edsb/assets"
+	"edsb/events"
+	"edsb/jobs"
+	"edsb/migrations"
+	"edsb/models"
+	"edsb/static"
+	"edsb/store"
+	"edsb/store/postgres"
+	"edsb/templates"
 	"edsb/views"
 
 	"github.com/gorilla/mux"
@@ -39,26 +49,82 @@ func main() {
 	}
 	defer db.Close()
 
-	// Cria as tabelas usando as funções nos arquivos de API
-	if err := user.CreateUsersTable(db); err != nil {
-		log.Fatalf("Erro ao criar tabela users: %v", err)
+	st := postgres.New(db)
+
+	// Subcomandos de linha de comando (ex.: ./app_edsb migrate status)
+	if len(os.Args) > 1 {
+		if err := runCommand(db, st, os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Aplica as migrações pendentes antes de subir o servidor
+	if _, err := migrations.Up(db); err != nil {
+		log.Fatalf("Erro ao aplicar migrações: %v", err)
+	}
+	log.Println("Banco de dados inicializado com sucesso.")
+
+	// Corrige periodicamente divergências nos contadores de likes
+	interval, err := jobs.IntervalFromEnv("LIKES_RECONCILE_INTERVAL", time.Hour)
+	if err != nil {
+		log.Fatal(err)
 	}
-	if err := post.CreatePostsTable(db); err != nil {
-		log.Fatalf("Erro ao criar tabela posts: %v", err)
+	jobs.Every("reconcile-likes", interval, func() error {
+		return reconcileLikes(st)
+	})
+
+	// Apaga de vez, periodicamente, o que foi removido há mais tempo que a
+	// retenção
+	retention, err := deletedRetention()
+	if err != nil {
+		log.Fatal(err)
 	}
-	if err := comment.CreateCommentsTable(db); err != nil {
-		log.Fatalf("Erro ao criar tabela comments: %v", err)
+	interval, err = jobs.IntervalFromEnv("PURGE_INTERVAL", time.Hour)
+	if err != nil {
+		log.Fatal(err)
 	}
-	if err := like.CreateLikesTable(db); err != nil {
-		log.Fatalf("Erro ao criar tabela likes: %v", err)
+	jobs.Every("purge-deleted", interval, func() error {
+		return purgeDeleted(st, retention)
+	})
+
+	// Profundidade máxima das respostas aninhadas em comentários
+	if v := os.Getenv("COMMENT_MAX_DEPTH"); v != "" {
+		depth, err := strconv.Atoi(v)
+		if err != nil || depth < 0 {
+			log.Fatalf("COMMENT_MAX_DEPTH inválido: %s", v)
+		}
+		comment.MaxDepth = depth
+	}
+
+	// Carrega os templates e arquivos estáticos: embutidos no binário ou, com
+	// EDSB_DEV=1, lidos dos diretórios templates/ e static/ (os templates são
+	// recarregados a cada alteração)
+	dev := os.Getenv("EDSB_DEV") != ""
+	templatesFS, staticFS := fs.FS(templates.FS), fs.FS(static.FS)
+	if dev {
+		templatesFS, staticFS = os.DirFS("templates"), os.DirFS("static")
+	}
+	if err := views.Load(templatesFS); err != nil {
+		log.Fatalf("Erro ao carregar templates: %v", err)
+	}
+	if dev {
+		views.Watch("templates", time.Second)
+	}
+	staticAssets, err := assets.New(staticFS)
+	if err != nil {
+		log.Fatalf("Erro ao carregar arquivos estáticos: %v", err)
+	}
+	// Com EDSB_VENDOR_ASSETS=1 as páginas usam as cópias locais das bibliotecas
+	if err := views.UseAssets(staticAssets, os.Getenv("EDSB_VENDOR_ASSETS") != ""); err != nil {
+		log.Fatal(err)
 	}
-	log.Println("Banco de dados inicializado com sucesso.")
 
 	// Configura o roteador
 	r := mux.NewRouter()
 
 	// Configura as rotas da API
-	routes.ConfigureRoutes(r, db)
+	routes.ConfigureRoutes(r, st, events.NewHub())
 
 	// Configura as rotas para os templates
 	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -73,9 +139,156 @@ func main() {
 		views.RenderTemplate(w, "login.html", nil)
 	}).Methods("GET")
 
+	// Arquivos estáticos (CSS, JS, imagens) ficam fora do roteador para não
+	// passarem pelo middleware de sessão
+	root := http.NewServeMux()
+	root.Handle(assets.Prefix, staticAssets)
+	root.Handle("/", r)
+
 	// Inicia o servidor
 	log.Println("Servidor rodando na porta :8081 em http://localhost:8081")
-	if err := http.ListenAndServe(":8080", r); err != nil {
+	if err := http.ListenAndServe(":8080", root); err != nil {
 		log.Fatalf("Erro ao iniciar o servidor: %v", err)
 	}
 }
+
+// runCommand executa um subcomando do binário em vez de subir o servidor
+func runCommand(db *sql.DB, st *store.Store, args []string) error {
+	switch args[0] {
+	case "migrate":
+		return runMigrate(db, args[1:])
+	case "reconcile-likes":
+		return reconcileLikes(st)
+	case "purge-deleted":
+		retention, err := deletedRetention()
+		if err != nil {
+			return err
+		}
+		return purgeDeleted(st, retention)
+	case "promote-admin":
+		return promoteAdmin(st, args[1:])
+	default:
+		return fmt.Errorf("comando desconhecido: %s (disponíveis: migrate, reconcile-likes, purge-deleted, promote-admin)", args[0])
+	}
+}
+
+// reconcileLikes recalcula likes_count de posts e comentários a partir da tabela likes
+func reconcileLikes(st *store.Store) error {
+	fixed, err := st.Likes.Reconcile()
+	if err != nil {
+		return err
+	}
+	log.Printf("Contadores de likes reconciliados: %d registro(s) corrigido(s).", fixed)
+	return nil
+}
+
+// deletedRetention lê o prazo de restauração (RESTORE_WINDOW, padrão 7 dias) e
+// por quanto tempo o que foi removido é mantido antes da limpeza
+// (DELETED_RETENTION, padrão 30 dias). A retenção não pode ser menor que o
+// prazo, senão a limpeza apagaria o que ainda pode ser restaurado.
+func deletedRetention() (time.Duration, error) {
+	window, err := jobs.IntervalFromEnv("RESTORE_WINDOW", models.RestoreWindow)
+	if err != nil {
+		return 0, err
+	}
+	retention, err := jobs.IntervalFromEnv("DELETED_RETENTION", 30*24*time.Hour)
+	if err != nil {
+		return 0, err
+	}
+	if retention < window {
+		return 0, fmt.Errorf("DELETED_RETENTION (%s) deve ser maior ou igual a RESTORE_WINDOW (%s)", retention, window)
+	}
+	models.RestoreWindow = window
+	return retention, nil
+}
+
+// purgeDeleted apaga de vez os comentários, posts e usuários removidos há mais
+// tempo que a retenção
+func purgeDeleted(st *store.Store, retention time.Duration) error {
+	before := time.Now().Add(-retention)
+	comments, err := st.Comments.Purge(before)
+	if err != nil {
+		return err
+	}
+	posts, err := st.Posts.Purge(before)
+	if err != nil {
+		return err
+	}
+	users, err := st.Users.Purge(before)
+	if err != nil {
+		return err
+	}
+	log.Printf("Limpeza de removidos: %d comentário(s), %d post(s) e %d usuário(s) apagado(s).", comments, posts, users)
+	return nil
+}
+
+// promoteAdmin implementa `promote-admin <id>`, que torna o usuário
+// administrador. É o único jeito de criar o primeiro administrador, já que pela
+// API só um administrador muda o papel de alguém.
+func promoteAdmin(st *store.Store, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("uso: promote-admin <id>")
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil || id <= 0 {
+		return fmt.Errorf("id de usuário inválido: %s", args[0])
+	}
+
+	user, err := st.Users.Get(id)
+	if errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("usuário %d não encontrado", id)
+	}
+	if err != nil {
+		return err
+	}
+	user.Role = models.RoleAdmin
+	if err := st.Users.Update(user); err != nil {
+		return err
+	}
+	log.Printf("Usuário %d (%s) promovido a administrador.", user.ID, user.Username)
+	return nil
+}
+
+// runMigrate implementa `migrate up`, `migrate down [n]` e `migrate status`
+func runMigrate(db *sql.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("uso: migrate up | down [n] | status")
+	}
+
+	switch args[0] {
+	case "up":
+		n, err := migrations.Up(db)
+		if err != nil {
+			return err
+		}
+		log.Printf("%d migração(ões) aplicada(s).", n)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				return fmt.Errorf("número de passos inválido: %s", args[1])
+			}
+		}
+		n, err := migrations.Down(db, steps)
+		if err != nil {
+			return err
+		}
+		log.Printf("%d migração(ões) revertida(s).", n)
+	case "status":
+		statuses, err := migrations.List(db)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pendente"
+			if s.Applied {
+				state = "aplicada em " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", s.Version, s.Name, state)
+		}
+	default:
+		return fmt.Errorf("subcomando de migrate desconhecido: %s", args[0])
+	}
+	return nil
+}
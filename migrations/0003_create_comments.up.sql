@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS comments (
+	id SERIAL PRIMARY KEY,
+	post_id INT REFERENCES posts(id) ON DELETE CASCADE,
+	user_id INT REFERENCES users(id) ON DELETE CASCADE,
+	content TEXT NOT NULL,
+	likes_count INT DEFAULT 0,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
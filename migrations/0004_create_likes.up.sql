@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS likes (
+	id SERIAL PRIMARY KEY,
+	user_id INT REFERENCES users(id) ON DELETE CASCADE,
+	post_id INT REFERENCES posts(id) ON DELETE CASCADE,
+	comment_id INT REFERENCES comments(id) ON DELETE CASCADE,
+	UNIQUE(user_id, post_id),
+	UNIQUE(user_id, comment_id)
+);
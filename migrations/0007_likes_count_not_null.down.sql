@@ -0,0 +1,2 @@
+ALTER TABLE posts ALTER COLUMN likes_count DROP NOT NULL;
+ALTER TABLE comments ALTER COLUMN likes_count DROP NOT NULL;
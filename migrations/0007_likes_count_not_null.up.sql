@@ -0,0 +1,5 @@
+UPDATE posts SET likes_count = 0 WHERE likes_count IS NULL;
+ALTER TABLE posts ALTER COLUMN likes_count SET NOT NULL;
+
+UPDATE comments SET likes_count = 0 WHERE likes_count IS NULL;
+ALTER TABLE comments ALTER COLUMN likes_count SET NOT NULL;
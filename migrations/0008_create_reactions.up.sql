@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS reactions (
+	id SERIAL PRIMARY KEY,
+	user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	post_id INT REFERENCES posts(id) ON DELETE CASCADE,
+	comment_id INT REFERENCES comments(id) ON DELETE CASCADE,
+	emoji VARCHAR(16) NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(user_id, post_id),
+	UNIQUE(user_id, comment_id),
+	CHECK ((post_id IS NULL) <> (comment_id IS NULL))
+);
+CREATE INDEX IF NOT EXISTS reactions_post_id_idx ON reactions (post_id);
+CREATE INDEX IF NOT EXISTS reactions_comment_id_idx ON reactions (comment_id);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS follows (
+	follower_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	followed_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (follower_id, followed_id),
+	CHECK (follower_id <> followed_id)
+);
+CREATE INDEX IF NOT EXISTS follows_followed_id_idx ON follows (followed_id, created_at DESC);
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS posts_user_id_created_at_id_idx ON posts (user_id, created_at DESC, id DESC);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS posts_top_score_id_idx;
+DROP INDEX IF EXISTS posts_hot_score_id_idx;
+ALTER TABLE posts DROP COLUMN IF EXISTS hot_score;
+DROP FUNCTION IF EXISTS hot_score(INT, INT, TIMESTAMP);
+DROP TRIGGER IF EXISTS comments_count_trigger ON comments;
+DROP FUNCTION IF EXISTS posts_comments_count();
+ALTER TABLE posts DROP COLUMN IF EXISTS comments_count;
@@ -0,0 +1,37 @@
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS comments_count INT NOT NULL DEFAULT 0;
+
+UPDATE posts p SET comments_count = c.total
+FROM (SELECT post_id, COUNT(*) AS total FROM comments GROUP BY post_id) c
+WHERE p.id = c.post_id;
+
+-- Mantém comments_count também nas remoções em cascata (respostas, usuários)
+CREATE OR REPLACE FUNCTION posts_comments_count() RETURNS TRIGGER AS $$
+BEGIN
+	IF TG_OP = 'INSERT' THEN
+		UPDATE posts SET comments_count = comments_count + 1 WHERE id = NEW.post_id;
+	ELSE
+		UPDATE posts SET comments_count = GREATEST(comments_count - 1, 0) WHERE id = OLD.post_id;
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER comments_count_trigger
+AFTER INSERT OR DELETE ON comments
+FOR EACH ROW EXECUTE FUNCTION posts_comments_count();
+
+-- Pontuação "hot": engajamento em escala logarítmica mais um termo que cresce
+-- com a data de publicação (45000s = 12,5h valem 10x mais engajamento). Deve
+-- acompanhar models.HotScore.
+CREATE OR REPLACE FUNCTION hot_score(likes INT, comments INT, created TIMESTAMP)
+RETURNS DOUBLE PRECISION AS $$
+	SELECT LOG(GREATEST(likes + 2 * comments, 1)::double precision)
+		+ (EXTRACT(EPOCH FROM created)::double precision - 1704067200) / 45000
+$$ LANGUAGE SQL IMMUTABLE;
+
+-- Recalculada pelo próprio banco sempre que likes_count ou comments_count mudam
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS hot_score DOUBLE PRECISION
+	GENERATED ALWAYS AS (hot_score(likes_count, comments_count, created_at)) STORED;
+
+CREATE INDEX IF NOT EXISTS posts_hot_score_id_idx ON posts (hot_score DESC, id DESC);
+CREATE INDEX IF NOT EXISTS posts_top_score_id_idx ON posts ((likes_count + comments_count) DESC, id DESC);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS comments_search_idx;
+DROP INDEX IF EXISTS posts_search_idx;
+ALTER TABLE comments DROP COLUMN IF EXISTS search;
+ALTER TABLE posts DROP COLUMN IF EXISTS search;
+DROP TEXT SEARCH CONFIGURATION IF EXISTS pt_unaccent;
+DROP EXTENSION IF EXISTS unaccent;
@@ -0,0 +1,25 @@
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- Configuração de busca em português que ignora acentos ("politica" encontra "política")
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'pt_unaccent') THEN
+		CREATE TEXT SEARCH CONFIGURATION pt_unaccent (COPY = portuguese);
+		ALTER TEXT SEARCH CONFIGURATION pt_unaccent
+			ALTER MAPPING FOR hword, hword_part, word WITH unaccent, portuguese_stem;
+	END IF;
+END
+$$;
+
+-- O título pesa mais que o conteúdo no ranking
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS search tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector('pt_unaccent', coalesce(title, '')), 'A') ||
+	setweight(to_tsvector('pt_unaccent', coalesce(content, '')), 'B')
+) STORED;
+
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS search tsvector GENERATED ALWAYS AS (
+	to_tsvector('pt_unaccent', coalesce(content, ''))
+) STORED;
+
+CREATE INDEX IF NOT EXISTS posts_search_idx ON posts USING GIN (search);
+CREATE INDEX IF NOT EXISTS comments_search_idx ON comments USING GIN (search);
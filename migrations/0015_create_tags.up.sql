@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS tags (
+	id SERIAL PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS post_tags (
+	post_id INT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+	tag_id INT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (post_id, tag_id)
+);
+CREATE INDEX IF NOT EXISTS post_tags_tag_id_idx ON post_tags (tag_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS post_tags_created_at_idx ON post_tags (created_at);
@@ -0,0 +1,25 @@
+-- Usuários mencionados (@username) em posts e comentários
+CREATE TABLE IF NOT EXISTS mentions (
+	id SERIAL PRIMARY KEY,
+	user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	post_id INT REFERENCES posts(id) ON DELETE CASCADE,
+	comment_id INT REFERENCES comments(id) ON DELETE CASCADE,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (user_id, post_id),
+	UNIQUE (user_id, comment_id)
+);
+CREATE INDEX IF NOT EXISTS mentions_post_id_idx ON mentions (post_id);
+CREATE INDEX IF NOT EXISTS mentions_comment_id_idx ON mentions (comment_id);
+
+-- Notificações enviadas a user_id sobre algo que actor_id fez
+CREATE TABLE IF NOT EXISTS notifications (
+	id SERIAL PRIMARY KEY,
+	user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	actor_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	type TEXT NOT NULL,
+	post_id INT REFERENCES posts(id) ON DELETE CASCADE,
+	comment_id INT REFERENCES comments(id) ON DELETE CASCADE,
+	read_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS notifications_user_id_idx ON notifications (user_id, created_at DESC, id DESC);
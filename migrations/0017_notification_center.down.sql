@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS notification_preferences;
+DROP INDEX IF EXISTS notifications_unread_unique_idx;
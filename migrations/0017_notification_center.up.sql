@@ -0,0 +1,14 @@
+-- Uma notificação não lida por autor, tipo e alvo: curtir, descurtir e curtir
+-- de novo não gera notificações repetidas
+CREATE UNIQUE INDEX IF NOT EXISTS notifications_unread_unique_idx
+	ON notifications (user_id, actor_id, type, COALESCE(post_id, 0), COALESCE(comment_id, 0))
+	WHERE read_at IS NULL;
+
+-- Tipos de notificação desativados (ou reativados) por cada usuário; sem
+-- registro o tipo fica ativo
+CREATE TABLE IF NOT EXISTS notification_preferences (
+	user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	type TEXT NOT NULL,
+	enabled BOOLEAN NOT NULL,
+	PRIMARY KEY (user_id, type)
+);
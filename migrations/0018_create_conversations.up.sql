@@ -0,0 +1,32 @@
+-- Conversas privadas entre dois usuários (diretas) ou pequenos grupos
+CREATE TABLE IF NOT EXISTS conversations (
+	id SERIAL PRIMARY KEY,
+	title TEXT NOT NULL DEFAULT '',
+	is_group BOOLEAN NOT NULL DEFAULT FALSE,
+	created_by INT REFERENCES users(id) ON DELETE SET NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	last_message_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Participantes de cada conversa. last_read_message_id é o recibo de leitura
+-- (última mensagem lida); left_at marca quem saiu da conversa.
+CREATE TABLE IF NOT EXISTS conversation_members (
+	conversation_id INT NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+	user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	joined_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	last_read_message_id INT,
+	last_read_at TIMESTAMP,
+	muted BOOLEAN NOT NULL DEFAULT FALSE,
+	left_at TIMESTAMP,
+	PRIMARY KEY (conversation_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS conversation_members_user_id_idx ON conversation_members (user_id) WHERE left_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS messages (
+	id SERIAL PRIMARY KEY,
+	conversation_id INT NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+	user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	content TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS messages_conversation_id_idx ON messages (conversation_id, created_at DESC, id DESC);
@@ -0,0 +1,25 @@
+-- Histórico de edições de posts e comentários. Na primeira edição são gravadas
+-- a versão original (1) e a nova; cada edição seguinte grava mais uma versão.
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS edited_at TIMESTAMP;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS edited_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS post_revisions (
+	id SERIAL PRIMARY KEY,
+	post_id INT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+	version INT NOT NULL,
+	editor_id INT REFERENCES users(id) ON DELETE SET NULL,
+	title TEXT NOT NULL,
+	content TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (post_id, version)
+);
+
+CREATE TABLE IF NOT EXISTS comment_revisions (
+	id SERIAL PRIMARY KEY,
+	comment_id INT NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+	version INT NOT NULL,
+	editor_id INT REFERENCES users(id) ON DELETE SET NULL,
+	content TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (comment_id, version)
+);
@@ -0,0 +1,33 @@
+-- Sem deleted_at o que está removido voltaria a aparecer, e apagá-lo levaria
+-- junto o que ainda pode ser restaurado e as respostas visíveis dos
+-- comentários removidos. Por isso a reversão é recusada enquanto houver
+-- removidos.
+DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM users WHERE deleted_at IS NOT NULL)
+		OR EXISTS (SELECT 1 FROM posts WHERE deleted_at IS NOT NULL)
+		OR EXISTS (SELECT 1 FROM comments WHERE deleted_at IS NOT NULL) THEN
+		RAISE EXCEPTION 'Existem usuários, posts ou comentários removidos; restaure-os ou aguarde a limpeza (purge-deleted) antes de reverter';
+	END IF;
+END
+$$;
+
+CREATE OR REPLACE FUNCTION posts_comments_count() RETURNS TRIGGER AS $$
+BEGIN
+	IF TG_OP = 'INSERT' THEN
+		UPDATE posts SET comments_count = comments_count + 1 WHERE id = NEW.post_id;
+	ELSE
+		UPDATE posts SET comments_count = GREATEST(comments_count - 1, 0) WHERE id = OLD.post_id;
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS comments_count_trigger ON comments;
+CREATE TRIGGER comments_count_trigger
+AFTER INSERT OR DELETE ON comments
+FOR EACH ROW EXECUTE FUNCTION posts_comments_count();
+
+ALTER TABLE comments DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE posts DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,4 @@
+-- Os comentários que já ficaram sem autor são mantidos
+ALTER TABLE comments DROP CONSTRAINT IF EXISTS comments_user_id_fkey;
+ALTER TABLE comments ADD CONSTRAINT comments_user_id_fkey
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
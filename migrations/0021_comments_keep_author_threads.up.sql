@@ -0,0 +1,5 @@
+-- Ao apagar de vez um usuário, seus comentários com respostas continuam na
+-- thread, sem autor, em vez de levar as respostas de outros usuários junto
+ALTER TABLE comments DROP CONSTRAINT IF EXISTS comments_user_id_fkey;
+ALTER TABLE comments ADD CONSTRAINT comments_user_id_fkey
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
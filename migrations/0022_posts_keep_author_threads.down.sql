@@ -0,0 +1,4 @@
+-- Os posts que já ficaram sem autor são mantidos
+ALTER TABLE posts DROP CONSTRAINT IF EXISTS posts_user_id_fkey;
+ALTER TABLE posts ADD CONSTRAINT posts_user_id_fkey
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
@@ -0,0 +1,5 @@
+-- Ao apagar de vez um usuário, seus posts com comentários continuam no ar,
+-- sem autor, em vez de levar a discussão de outros usuários junto
+ALTER TABLE posts DROP CONSTRAINT IF EXISTS posts_user_id_fkey;
+ALTER TABLE posts ADD CONSTRAINT posts_user_id_fkey
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
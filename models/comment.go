@@ -5,9 +5,26 @@ import "time"
 
 // Comment representa a estrutura de um comentário
 type Comment struct {
-	ID        int       `json:"id"`
-	PostID    int       `json:"post_id"`
-	UserID    int       `json:"user_id"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         int        `json:"id"`
+	PostID     int        `json:"post_id"`
+	UserID     int        `json:"user_id"`
+	ParentID   *int       `json:"parent_id"` // nil para comentários de primeiro nível
+	Depth      int        `json:"depth"`     // 0 para comentários de primeiro nível
+	Content    string     `json:"content"`
+	LikesCount int        `json:"likes_count"`
+	CreatedAt  time.Time  `json:"created_at"`
+	EditedAt   *time.Time `json:"edited_at"`            // última edição; nil se nunca foi editado
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"` // remoção lógica; nil se não foi removido
+
+	Reactions map[string]int `json:"reactions,omitempty"` // contagem por emoji, preenchida no GET individual
+	Replies   []Comment      `json:"replies,omitempty"`   // respostas, preenchidas no modo árvore
+}
+
+// Redact esconde o conteúdo e o autor de um comentário removido, que continua
+// nas listagens para manter a thread de respostas
+func (c *Comment) Redact() {
+	if c.DeletedAt != nil {
+		c.Content = RemovedContent
+		c.UserID = 0
+	}
 }
@@ -0,0 +1,37 @@
+// models/conversation.go
+package models
+
+import "time"
+
+// Conversation é uma conversa privada, direta (dois usuários) ou em grupo.
+// Muted e UnreadCount são do ponto de vista de quem consulta.
+type Conversation struct {
+	ID            int                  `json:"id"`
+	Title         string               `json:"title,omitempty"`
+	IsGroup       bool                 `json:"is_group"`
+	CreatedBy     int                  `json:"created_by"`
+	CreatedAt     time.Time            `json:"created_at"`
+	LastMessageAt time.Time            `json:"last_message_at"`
+	Members       []ConversationMember `json:"members"`
+	Muted         bool                 `json:"muted"`
+	UnreadCount   int                  `json:"unread_count"`
+}
+
+// ConversationMember é um participante da conversa com seu recibo de leitura
+type ConversationMember struct {
+	UserID            int        `json:"user_id"`
+	Username          string     `json:"username"`
+	JoinedAt          time.Time  `json:"joined_at"`
+	LastReadMessageID int        `json:"last_read_message_id,omitempty"` // 0 se ainda não leu nada
+	LastReadAt        *time.Time `json:"last_read_at,omitempty"`
+	LeftAt            *time.Time `json:"left_at,omitempty"` // nil enquanto participa
+}
+
+// Message é uma mensagem enviada numa conversa
+type Message struct {
+	ID             int       `json:"id"`
+	ConversationID int       `json:"conversation_id"`
+	UserID         int       `json:"user_id"`
+	Content        string    `json:"content"`
+	CreatedAt      time.Time `json:"created_at"`
+}
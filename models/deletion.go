@@ -0,0 +1,17 @@
+// models/deletion.go
+package models
+
+import "time"
+
+// RemovedContent substitui o conteúdo dos comentários removidos
+const RemovedContent = "[removido]"
+
+// RestoreWindow é o prazo, a partir da remoção, para restaurar um usuário,
+// post ou comentário. Deve ser menor que o tempo de retenção usado pela
+// rotina de limpeza, que apaga os removidos de vez.
+var RestoreWindow = 7 * 24 * time.Hour
+
+// Restorable indica se algo removido em deletedAt ainda pode ser restaurado
+func Restorable(deletedAt time.Time) bool {
+	return time.Since(deletedAt) <= RestoreWindow
+}
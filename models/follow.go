@@ -0,0 +1,25 @@
+// models/follow.go
+package models
+
+import "time"
+
+// Follow representa a relação "follower segue followed"
+type Follow struct {
+	FollowerID int       `json:"follower_id"`
+	FollowedID int       `json:"followed_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Connection é um usuário numa lista de seguidores/seguidos, com a data em
+// que a relação começou
+type Connection struct {
+	User
+	Since time.Time `json:"since"`
+}
+
+// Profile é o usuário com os números do seu grafo social
+type Profile struct {
+	User
+	FollowersCount int `json:"followers_count"`
+	FollowingCount int `json:"following_count"`
+}
@@ -0,0 +1,25 @@
+// models/mention.go
+package models
+
+import "regexp"
+
+// MentionPattern reconhece @username no início do texto ou depois de um
+// caractere que não faz parte de palavra nem de URL (evita emails como
+// ana@exemplo.com e endereços como https://exemplo.com/@ana).
+// O grupo 1 é o que vem antes do @ e o grupo 2 é o username, que não termina
+// em ponto para que "@ana." no fim da frase encontre "ana".
+var MentionPattern = regexp.MustCompile(`(^|[^\p{L}\p{N}_.@/])@([\p{L}\p{N}_](?:[\p{L}\p{N}_.]*[\p{L}\p{N}_])?)`)
+
+// ParseMentions extrai os usernames mencionados no texto, sem repetição e na
+// ordem em que aparecem
+func ParseMentions(text string) []string {
+	var usernames []string
+	seen := map[string]bool{}
+	for _, m := range MentionPattern.FindAllStringSubmatch(text, -1) {
+		if !seen[m[2]] {
+			seen[m[2]] = true
+			usernames = append(usernames, m[2])
+		}
+	}
+	return usernames
+}
@@ -0,0 +1,97 @@
+// models/notification.go
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Tipos de notificação. PostID e CommentID indicam sobre o que é a
+// notificação: o post ou comentário curtido, o post comentado, o comentário
+// respondido ou o conteúdo em que o usuário foi mencionado.
+const (
+	NotificationLike    = "like"    // curtiram um post ou comentário do usuário
+	NotificationComment = "comment" // comentaram num post do usuário
+	NotificationReply   = "reply"   // responderam a um comentário do usuário
+	NotificationMention = "mention" // o usuário foi mencionado num post ou comentário
+	NotificationFollow  = "follow"  // começaram a seguir o usuário
+)
+
+// NotificationTypes lista os tipos de notificação, na ordem em que as
+// preferências são mostradas
+var NotificationTypes = []string{NotificationLike, NotificationComment, NotificationReply, NotificationMention, NotificationFollow}
+
+// Notification avisa UserID de algo que ActorID fez
+type Notification struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	ActorID   int        `json:"actor_id"`
+	Type      string     `json:"type"`
+	PostID    int        `json:"post_id,omitempty"`
+	CommentID int        `json:"comment_id,omitempty"`
+	ReadAt    *time.Time `json:"read_at"` // nil enquanto não lida
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NotificationActor é quem causou uma notificação
+type NotificationActor struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+}
+
+// NotificationGroup reúne as notificações de mesmo tipo sobre o mesmo alvo
+// (ex.: todos os likes num post) que estão no mesmo estado, lidas ou não
+type NotificationGroup struct {
+	ID          int                 `json:"id"` // notificação mais recente do grupo
+	Type        string              `json:"type"`
+	PostID      int                 `json:"post_id,omitempty"`
+	CommentID   int                 `json:"comment_id,omitempty"`
+	Actors      []NotificationActor `json:"actors"` // os mais recentes, no máximo MaxGroupActors
+	ActorsCount int                 `json:"actors_count"`
+	Read        bool                `json:"read"`
+	Message     string              `json:"message"`
+	CreatedAt   time.Time           `json:"created_at"` // da notificação mais recente
+}
+
+// MaxGroupActors é quantos autores cada NotificationGroup traz
+const MaxGroupActors = 3
+
+// Describe resume o grupo numa frase como "Ana e mais 4 curtiram seu post"
+func (g NotificationGroup) Describe() string {
+	if len(g.Actors) == 0 {
+		return ""
+	}
+	var who string
+	switch {
+	case g.ActorsCount <= 1:
+		who = g.Actors[0].Username
+	case g.ActorsCount == 2 && len(g.Actors) > 1:
+		who = g.Actors[0].Username + " e " + g.Actors[1].Username
+	default:
+		who = fmt.Sprintf("%s e mais %d", g.Actors[0].Username, g.ActorsCount-1)
+	}
+
+	singular := g.ActorsCount <= 1
+	verb := func(one, many string) string {
+		if singular {
+			return one
+		}
+		return many
+	}
+	switch g.Type {
+	case NotificationLike:
+		if g.CommentID != 0 {
+			return who + " " + verb("curtiu", "curtiram") + " seu comentário"
+		}
+		return who + " " + verb("curtiu", "curtiram") + " seu post"
+	case NotificationComment:
+		return who + " " + verb("comentou", "comentaram") + " no seu post"
+	case NotificationReply:
+		return who + " " + verb("respondeu", "responderam") + " ao seu comentário"
+	case NotificationMention:
+		return who + " " + verb("mencionou", "mencionaram") + " você"
+	case NotificationFollow:
+		return who + " " + verb("começou", "começaram") + " a seguir você"
+	}
+	return who
+}
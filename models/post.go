@@ -1,13 +1,39 @@
 // models/post.go
 package models
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
 // Post representa a estrutura de um post
 type Post struct {
-	ID        int       `json:"id"`
-	UserID    int       `json:"user_id"` // ID do usuário que criou o post
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	ID            int        `json:"id"`
+	UserID        int        `json:"user_id"` // ID do usuário que criou o post
+	Title         string     `json:"title"`
+	Content       string     `json:"content"`
+	LikesCount    int        `json:"likes_count"`
+	CommentsCount int        `json:"comments_count"`
+	CreatedAt     time.Time  `json:"created_at"`
+	EditedAt      *time.Time `json:"edited_at"`            // última edição; nil se nunca foi editado
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"` // remoção lógica; nil se não foi removido
+
+	Tags      []string       `json:"tags,omitempty"`      // hashtags do conteúdo, ver ParseHashtags
+	HotScore  float64        `json:"-"`                   // pontuação do ranking "hot", ver HotScore
+	Reactions map[string]int `json:"reactions,omitempty"` // contagem por emoji, preenchida no GET individual
+}
+
+// hotEpoch e hotDecay definem o termo temporal de HotScore
+const (
+	hotEpoch = 1704067200 // 2024-01-01 00:00:00 UTC
+	hotDecay = 45000      // segundos que valem 10x mais engajamento
+)
+
+// HotScore calcula a pontuação do ranking "hot": o engajamento (likes + 2 ×
+// comentários) em escala logarítmica somado a um termo que cresce com a data
+// de publicação, de modo que posts novos ultrapassam os antigos com o tempo.
+// Espelha a função SQL hot_score, mantida pelo banco a cada mudança de contador.
+func HotScore(likes, comments int, createdAt time.Time) float64 {
+	engagement := math.Max(float64(likes+2*comments), 1)
+	return math.Log10(engagement) + float64(createdAt.Unix()-hotEpoch)/hotDecay
 }
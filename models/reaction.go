@@ -0,0 +1,29 @@
+// models/reaction.go
+package models
+
+import "time"
+
+// Reactions é o conjunto de reações aceitas em posts e comentários
+var Reactions = []string{"👍", "😂", "😢", "😡", "🇧🇷"}
+
+// Reaction representa a reação (emoji) de um usuário a um post ou comentário.
+// Cada usuário tem no máximo uma reação por post/comentário.
+type Reaction struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Username  string    `json:"username,omitempty"`
+	PostID    int       `json:"post_id,omitempty"`
+	CommentID int       `json:"comment_id,omitempty"`
+	Emoji     string    `json:"emoji"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ValidReaction indica se o emoji pertence ao conjunto de reações aceitas
+func ValidReaction(emoji string) bool {
+	for _, r := range Reactions {
+		if r == emoji {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,14 @@
+// models/revision.go
+package models
+
+import "time"
+
+// Revision é uma versão de um post ou comentário. A versão 1 é o conteúdo
+// original, gravado na primeira edição junto com a versão 2.
+type Revision struct {
+	Version   int       `json:"version"`
+	EditorID  int       `json:"editor_id"`       // autor da versão (da original, o autor do conteúdo); 0 se a conta foi removida
+	Title     string    `json:"title,omitempty"` // vazio em comentários
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
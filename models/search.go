@@ -0,0 +1,22 @@
+// models/search.go
+package models
+
+import "time"
+
+// Tipos de resultado da busca
+const (
+	SearchPost    = "post"
+	SearchComment = "comment"
+)
+
+// SearchResult é um post ou comentário encontrado pela busca textual
+type SearchResult struct {
+	Type      string    `json:"type"` // SearchPost ou SearchComment
+	ID        int       `json:"id"`
+	PostID    int       `json:"post_id"` // o próprio post, ou o post do comentário
+	UserID    int       `json:"user_id"`
+	Title     string    `json:"title,omitempty"` // somente em posts
+	Snippet   string    `json:"snippet"`         // trecho em HTML escapado, com os termos encontrados em <mark>
+	Rank      float64   `json:"rank"`
+	CreatedAt time.Time `json:"created_at"`
+}
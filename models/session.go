@@ -0,0 +1,12 @@
+// models/session.go
+package models
+
+import "time"
+
+// Session representa uma sessão de login ativa de um usuário
+type Session struct {
+	ID        string    `json:"id"` // UUID opaco enviado no cookie
+	UserID    int       `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,48 @@
+// models/tag.go
+package models
+
+import (
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// TrendingTag é uma hashtag com seu uso recente, comparado à janela anterior
+type TrendingTag struct {
+	Name          string  `json:"name"`
+	Count         int     `json:"count"`          // usos na janela atual
+	PreviousCount int     `json:"previous_count"` // usos na janela anterior, de mesma duração
+	Velocity      float64 `json:"velocity"`       // variação de usos por hora entre as duas janelas
+}
+
+// hashtagPattern reconhece #tag no início do texto ou depois de um caractere
+// que não faz parte de palavra (evita âncoras como pagina#secao)
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&/])#([\p{L}\p{N}_]{1,50})`)
+
+// ParseHashtags extrai as hashtags do texto, normalizadas em minúsculas, sem
+// repetição e na ordem em que aparecem. Tags só com números (#1) são ignoradas.
+func ParseHashtags(text string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	for _, m := range hashtagPattern.FindAllStringSubmatch(text, -1) {
+		tag := NormalizeTag(m[1])
+		if seen[tag] || strings.IndexFunc(tag, unicode.IsLetter) < 0 {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// NormalizeTag converte o nome da tag para a forma armazenada (sem # e em
+// minúsculas)
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
+
+// TagVelocity é a variação de usos por hora entre a janela anterior e a atual
+func TagVelocity(count, previousCount int, window time.Duration) float64 {
+	return float64(count-previousCount) / window.Hours()
+}
@@ -3,11 +3,29 @@ package models
 
 import "time"
 
+// Papéis (roles) que um usuário pode ter no sistema
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
 // User representa um usuário do sistema
 type User struct {
-	ID        int       `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	Password  string    `json:"-"` // omitido em respostas JSON // Utilizado somente para registro, pois não pode ser retornado em GET
-	CreatedAt time.Time `json:"created_at"`
+	ID        int        `json:"id"`
+	Username  string     `json:"username"`
+	Email     string     `json:"email"`
+	Password  string     `json:"-"` // omitido em respostas JSON // Utilizado somente para registro, pois não pode ser retornado em GET
+	Role      string     `json:"role"`
+	CreatedAt time.Time  `json:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"` // remoção lógica; nil se não foi removido
+}
+
+// ValidRole indica se o papel informado é conhecido pelo sistema
+func ValidRole(role string) bool {
+	switch role {
+	case RoleUser, RoleModerator, RoleAdmin:
+		return true
+	}
+	return false
 }
@@ -0,0 +1,36 @@
+#!/bin/sh
+# Baixa para static/vendor as bibliotecas que as páginas carregam das CDNs e
+# gera as variantes pré-comprimidas (.gz e, se o brotli estiver instalado, .br).
+# Depois de executar, recompile e suba a aplicação com EDSB_VENDOR_ASSETS=1.
+set -eu
+
+cd "$(dirname "$0")/../static"
+
+BOOTSTRAP=4.5.2
+HTMX=2.0.3
+HTMX_SSE=2.2.2
+FONTAWESOME=6.0.0-beta3
+
+fetch() {
+	mkdir -p "$(dirname "$2")"
+	echo "baixando $2"
+	curl -fsSL "$1" -o "$2"
+}
+
+fetch "https://stackpath.bootstrapcdn.com/bootstrap/$BOOTSTRAP/css/bootstrap.min.css" vendor/bootstrap/bootstrap.min.css
+fetch "https://stackpath.bootstrapcdn.com/bootstrap/$BOOTSTRAP/js/bootstrap.bundle.min.js" vendor/bootstrap/bootstrap.bundle.min.js
+fetch "https://unpkg.com/htmx.org@$HTMX/dist/htmx.min.js" vendor/htmx/htmx.min.js
+fetch "https://unpkg.com/htmx-ext-sse@$HTMX_SSE/sse.js" vendor/htmx/sse.js
+fetch "https://cdnjs.cloudflare.com/ajax/libs/font-awesome/$FONTAWESOME/css/all.min.css" vendor/fontawesome/css/all.min.css
+for font in fa-brands-400 fa-regular-400 fa-solid-900; do
+	for ext in woff2 ttf; do
+		fetch "https://cdnjs.cloudflare.com/ajax/libs/font-awesome/$FONTAWESOME/webfonts/$font.$ext" "vendor/fontawesome/webfonts/$font.$ext"
+	done
+done
+
+find vendor -type f \( -name '*.css' -o -name '*.js' \) | while read -r file; do
+	gzip -9 -k -f "$file"
+	if command -v brotli >/dev/null 2>&1; then
+		brotli -q 11 -k -f "$file"
+	fi
+done
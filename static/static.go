@@ -0,0 +1,10 @@
+// Package static embute os arquivos estáticos (CSS, JS, imagens e, se
+// baixados com scripts/vendor-assets.sh, as bibliotecas em vendor/) no binário
+package static
+
+import "embed"
+
+// FS contém todos os arquivos deste diretório
+//
+//go:embed *
+var FS embed.FS
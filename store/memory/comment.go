@@ -0,0 +1,236 @@
+// comment.go
+package memory
+
+import (
+	"edsb/models"
+	"edsb/store"
+	"sort"
+	"time"
+)
+
+type commentStore struct {
+	*data
+}
+
+func (s *commentStore) Create(comment *models.Comment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.livePost(comment.PostID); !ok {
+		return store.ErrNotFound
+	}
+	if _, ok := s.users[comment.UserID]; !ok {
+		return store.ErrNotFound
+	}
+	if comment.ParentID != nil {
+		if _, ok := s.comments[*comment.ParentID]; !ok {
+			return store.ErrNotFound
+		}
+		parentID := *comment.ParentID
+		comment.ParentID = &parentID
+	}
+
+	comment.ID = s.nextID("comments")
+	comment.CreatedAt = time.Now()
+	s.comments[comment.ID] = *comment
+	return nil
+}
+
+func (s *commentStore) Get(id int) (*models.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comment, ok := s.liveComment(id)
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	comment = s.withLikes(comment)
+	return &comment, nil
+}
+
+func (s *commentStore) List(page store.Page) ([]models.Comment, *store.Cursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comments := make([]models.Comment, 0, len(s.comments))
+	for _, comment := range s.comments {
+		if comment.DeletedAt == nil {
+			comments = append(comments, s.withLikes(comment))
+		}
+	}
+
+	comments, next := paginate(comments, page, func(comment models.Comment) store.Cursor {
+		return store.Cursor{CreatedAt: comment.CreatedAt, ID: comment.ID}
+	})
+	return comments, next, nil
+}
+
+func (s *commentStore) ListByPost(postID int, page store.Page) ([]models.Comment, *store.Cursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var comments []models.Comment
+	for _, comment := range s.comments {
+		if comment.PostID == postID && comment.ParentID == nil && s.visible(comment) {
+			comments = append(comments, s.withLikes(comment))
+		}
+	}
+
+	comments, next := paginate(comments, page, func(comment models.Comment) store.Cursor {
+		return store.Cursor{CreatedAt: comment.CreatedAt, ID: comment.ID}
+	})
+	return comments, next, nil
+}
+
+func (s *commentStore) Replies(rootIDs []int) ([]models.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Percorre a árvore em largura a partir das raízes
+	replies := []models.Comment{}
+	frontier := map[int]bool{}
+	for _, id := range rootIDs {
+		frontier[id] = true
+	}
+	for len(frontier) > 0 {
+		next := map[int]bool{}
+		for _, comment := range s.comments {
+			if comment.ParentID != nil && frontier[*comment.ParentID] {
+				if s.visible(comment) {
+					replies = append(replies, s.withLikes(comment))
+				}
+				next[comment.ID] = true
+			}
+		}
+		frontier = next
+	}
+
+	sort.Slice(replies, func(i, j int) bool {
+		if !replies[i].CreatedAt.Equal(replies[j].CreatedAt) {
+			return replies[i].CreatedAt.Before(replies[j].CreatedAt)
+		}
+		return replies[i].ID < replies[j].ID
+	})
+	return replies, nil
+}
+
+func (s *commentStore) Update(comment *models.Comment, editorID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.liveComment(comment.ID)
+	if !ok {
+		return store.ErrNotFound
+	}
+	if current.Content == comment.Content {
+		return nil
+	}
+
+	now := time.Now()
+	s.commentRevisions[comment.ID] = addRevision(s.commentRevisions[comment.ID],
+		models.Revision{EditorID: current.UserID, Content: current.Content, CreatedAt: current.CreatedAt},
+		models.Revision{EditorID: editorID, Content: comment.Content, CreatedAt: now},
+	)
+	current.Content = comment.Content
+	current.EditedAt = &now
+	s.comments[comment.ID] = current
+	return nil
+}
+
+func (s *commentStore) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comment, ok := s.liveComment(id)
+	if !ok {
+		return store.ErrNotFound
+	}
+	now := time.Now()
+	comment.DeletedAt = &now
+	s.comments[id] = comment
+	return nil
+}
+
+func (s *commentStore) Deleted(id int) (*models.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comment, ok := s.comments[id]
+	if !ok || comment.DeletedAt == nil {
+		return nil, store.ErrNotFound
+	}
+	comment.LikesCount = s.counts[targetKey{CommentID: comment.ID}]
+	return &comment, nil
+}
+
+func (s *commentStore) Restore(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Comentários cujo autor foi apagado de vez não voltam
+	comment, ok := s.comments[id]
+	if !ok || comment.DeletedAt == nil || comment.UserID == 0 {
+		return store.ErrNotFound
+	}
+	comment.DeletedAt = nil
+	s.comments[id] = comment
+	return nil
+}
+
+func (s *commentStore) Purge(deletedBefore time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Como no banco, só saem os comentários sem respostas, repetindo até que
+	// não sobre nenhum
+	var purged int64
+	for {
+		var ids []int
+		for id, c := range s.comments {
+			if c.DeletedAt != nil && c.DeletedAt.Before(deletedBefore) && !s.hasReplies(id) {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) == 0 {
+			return purged, nil
+		}
+		for _, id := range ids {
+			s.deleteComment(id)
+		}
+		purged += int64(len(ids))
+	}
+}
+
+// visible indica se o comentário aparece na thread: os removidos continuam
+// enquanto tiverem respostas
+func (d *data) visible(comment models.Comment) bool {
+	return comment.DeletedAt == nil || d.hasReplies(comment.ID)
+}
+
+// withLikes preenche o contador de likes do comentário, que no banco é a
+// coluna likes_count, e esconde o conteúdo dos removidos
+func (d *data) withLikes(comment models.Comment) models.Comment {
+	comment.LikesCount = d.counts[targetKey{CommentID: comment.ID}]
+	comment.Redact()
+	return comment
+}
+
+func (s *commentStore) Revisions(commentID int) ([]models.Revision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.liveComment(commentID); !ok {
+		return nil, store.ErrNotFound
+	}
+	return newestFirst(s.commentRevisions[commentID]), nil
+}
+
+func (s *commentStore) Revision(commentID, version int) (*models.Revision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.liveComment(commentID); !ok {
+		return nil, store.ErrNotFound
+	}
+	return findRevision(s.commentRevisions[commentID], version)
+}
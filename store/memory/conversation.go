@@ -0,0 +1,232 @@
+// conversation.go
+package memory
+
+import (
+	"edsb/models"
+	"edsb/store"
+	"sort"
+	"time"
+)
+
+type conversationStore struct {
+	*data
+}
+
+// memberKey reproduz a chave primária (conversation_id, user_id) de
+// conversation_members
+type memberKey struct {
+	ConversationID int
+	UserID         int
+}
+
+// member é uma linha de conversation_members
+type member struct {
+	models.ConversationMember
+	Muted bool
+}
+
+func (s *conversationStore) Create(conv *models.Conversation, memberIDs []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range append([]int{conv.CreatedBy}, memberIDs...) {
+		if _, ok := s.users[id]; !ok {
+			return store.ErrNotFound
+		}
+	}
+
+	conv.ID = s.nextID("conversations")
+	conv.CreatedAt = time.Now()
+	conv.LastMessageAt = conv.CreatedAt
+	s.conversations[conv.ID] = models.Conversation{
+		ID: conv.ID, Title: conv.Title, IsGroup: conv.IsGroup, CreatedBy: conv.CreatedBy,
+		CreatedAt: conv.CreatedAt, LastMessageAt: conv.LastMessageAt,
+	}
+	for _, id := range memberIDs {
+		s.members[memberKey{conv.ID, id}] = member{ConversationMember: models.ConversationMember{UserID: id, JoinedAt: conv.CreatedAt}}
+	}
+	*conv = s.view(s.conversations[conv.ID], conv.CreatedBy)
+	return nil
+}
+
+func (s *conversationStore) FindDirect(userID, otherID int) (*models.Conversation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var found *models.Conversation
+	for _, c := range s.conversations {
+		if c.IsGroup || !s.isMember(c.ID, userID) || !s.isMember(c.ID, otherID) {
+			continue
+		}
+		if found == nil || c.ID < found.ID {
+			conv := s.view(c, userID)
+			found = &conv
+		}
+	}
+	if found == nil {
+		return nil, store.ErrNotFound
+	}
+	return found, nil
+}
+
+func (s *conversationStore) Get(id, userID int) (*models.Conversation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.conversations[id]
+	if !ok || !s.isMember(id, userID) {
+		return nil, store.ErrNotFound
+	}
+	conv := s.view(c, userID)
+	return &conv, nil
+}
+
+func (s *conversationStore) List(userID int, page store.Page) ([]models.Conversation, *store.Cursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var convs []models.Conversation
+	for _, c := range s.conversations {
+		if s.isMember(c.ID, userID) {
+			convs = append(convs, s.view(c, userID))
+		}
+	}
+	convs, next := paginate(convs, page, func(c models.Conversation) store.Cursor {
+		return store.Cursor{CreatedAt: c.LastMessageAt, ID: c.ID}
+	})
+	return convs, next, nil
+}
+
+func (s *conversationStore) Send(msg *models.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isMember(msg.ConversationID, msg.UserID) {
+		return store.ErrNotFound
+	}
+
+	msg.ID = s.nextID("messages")
+	msg.CreatedAt = time.Now()
+	s.messages[msg.ID] = *msg
+
+	c := s.conversations[msg.ConversationID]
+	c.LastMessageAt = msg.CreatedAt
+	s.conversations[c.ID] = c
+
+	key := memberKey{msg.ConversationID, msg.UserID}
+	m := s.members[key]
+	m.LastReadMessageID = msg.ID
+	m.LastReadAt = &msg.CreatedAt
+	s.members[key] = m
+	return nil
+}
+
+func (s *conversationStore) Messages(id, userID int, page store.Page) ([]models.Message, *store.Cursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isMember(id, userID) {
+		return nil, nil, store.ErrNotFound
+	}
+
+	var messages []models.Message
+	for _, msg := range s.messages {
+		if msg.ConversationID == id {
+			messages = append(messages, msg)
+		}
+	}
+	messages, next := paginate(messages, page, func(msg models.Message) store.Cursor {
+		return store.Cursor{CreatedAt: msg.CreatedAt, ID: msg.ID}
+	})
+	return messages, next, nil
+}
+
+func (s *conversationStore) MarkRead(id, userID, messageID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isMember(id, userID) {
+		return store.ErrNotFound
+	}
+
+	target := 0
+	for _, msg := range s.messages {
+		if msg.ConversationID == id && (messageID == 0 || msg.ID == messageID) && msg.ID > target {
+			target = msg.ID
+		}
+	}
+	if target == 0 {
+		if messageID != 0 {
+			return store.ErrNotFound
+		}
+		return nil // conversa sem mensagens
+	}
+
+	key := memberKey{id, userID}
+	m := s.members[key]
+	if target > m.LastReadMessageID {
+		m.LastReadMessageID = target
+	}
+	now := time.Now()
+	m.LastReadAt = &now
+	s.members[key] = m
+	return nil
+}
+
+func (s *conversationStore) SetMuted(id, userID int, muted bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isMember(id, userID) {
+		return store.ErrNotFound
+	}
+	key := memberKey{id, userID}
+	m := s.members[key]
+	m.Muted = muted
+	s.members[key] = m
+	return nil
+}
+
+func (s *conversationStore) Leave(id, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isMember(id, userID) {
+		return store.ErrNotFound
+	}
+	key := memberKey{id, userID}
+	m := s.members[key]
+	now := time.Now()
+	m.LeftAt = &now
+	s.members[key] = m
+	return nil
+}
+
+// isMember indica se o usuário participa da conversa e não saiu dela
+func (d *data) isMember(id, userID int) bool {
+	m, ok := d.members[memberKey{id, userID}]
+	return ok && m.LeftAt == nil
+}
+
+// view monta a conversa do ponto de vista do participante: seus membros, se
+// ele a silenciou e quantas mensagens de outros ainda não leu
+func (d *data) view(c models.Conversation, userID int) models.Conversation {
+	self := d.members[memberKey{c.ID, userID}]
+	c.Muted = self.Muted
+	c.UnreadCount = 0
+	for _, msg := range d.messages {
+		if msg.ConversationID == c.ID && msg.UserID != userID && msg.ID > self.LastReadMessageID {
+			c.UnreadCount++
+		}
+	}
+
+	c.Members = nil
+	for k, m := range d.members {
+		if k.ConversationID == c.ID {
+			m.Username = d.users[k.UserID].Username
+			c.Members = append(c.Members, m.ConversationMember)
+		}
+	}
+	sort.Slice(c.Members, func(i, j int) bool { return c.Members[i].UserID < c.Members[j].UserID })
+	return c
+}
@@ -0,0 +1,29 @@
+// feed.go
+package memory
+
+import (
+	"edsb/models"
+	"edsb/store"
+)
+
+type feedStore struct {
+	*data
+}
+
+func (s *feedStore) Home(userID int, page store.Page) ([]models.Post, *store.Cursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var posts []models.Post
+	for _, post := range s.posts {
+		_, follows := s.follows[followKey{FollowerID: userID, FollowedID: post.UserID}]
+		if post.DeletedAt == nil && (post.UserID == userID || follows) {
+			posts = append(posts, s.withCounts(post))
+		}
+	}
+
+	posts, next := paginate(posts, page, func(post models.Post) store.Cursor {
+		return store.Cursor{CreatedAt: post.CreatedAt, ID: post.ID}
+	})
+	return posts, next, nil
+}
@@ -0,0 +1,94 @@
+// follow.go
+package memory
+
+import (
+	"edsb/models"
+	"edsb/store"
+	"time"
+)
+
+type followStore struct {
+	*data
+}
+
+func (s *followStore) Follow(followerID, followedID int) (*models.Follow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[followerID]; !ok {
+		return nil, store.ErrNotFound
+	}
+	if _, ok := s.liveUser(followedID); !ok {
+		return nil, store.ErrNotFound
+	}
+
+	key := followKey{FollowerID: followerID, FollowedID: followedID}
+	if _, ok := s.follows[key]; ok {
+		return nil, store.ErrConflict
+	}
+
+	follow := models.Follow{FollowerID: followerID, FollowedID: followedID, CreatedAt: time.Now()}
+	s.follows[key] = follow
+	return &follow, nil
+}
+
+func (s *followStore) Unfollow(followerID, followedID int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := followKey{FollowerID: followerID, FollowedID: followedID}
+	if _, ok := s.follows[key]; !ok {
+		return false, nil
+	}
+	delete(s.follows, key)
+	return true, nil
+}
+
+func (s *followStore) Followers(userID int, page store.Page) ([]models.Connection, *store.Cursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var connections []models.Connection
+	for k, follow := range s.follows {
+		if user, ok := s.liveUser(k.FollowerID); ok && k.FollowedID == userID {
+			connections = append(connections, models.Connection{User: user, Since: follow.CreatedAt})
+		}
+	}
+	return s.page(connections, page)
+}
+
+func (s *followStore) Following(userID int, page store.Page) ([]models.Connection, *store.Cursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var connections []models.Connection
+	for k, follow := range s.follows {
+		if user, ok := s.liveUser(k.FollowedID); ok && k.FollowerID == userID {
+			connections = append(connections, models.Connection{User: user, Since: follow.CreatedAt})
+		}
+	}
+	return s.page(connections, page)
+}
+
+func (s *followStore) Counts(userID int) (int, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var followers, following int
+	for k := range s.follows {
+		if _, ok := s.liveUser(k.FollowerID); ok && k.FollowedID == userID {
+			followers++
+		}
+		if _, ok := s.liveUser(k.FollowedID); ok && k.FollowerID == userID {
+			following++
+		}
+	}
+	return followers, following, nil
+}
+
+func (s *followStore) page(connections []models.Connection, page store.Page) ([]models.Connection, *store.Cursor, error) {
+	connections, next := paginate(connections, page, func(c models.Connection) store.Cursor {
+		return store.Cursor{CreatedAt: c.Since, ID: c.ID}
+	})
+	return connections, next, nil
+}
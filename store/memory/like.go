@@ -0,0 +1,145 @@
+// like.go
+package memory
+
+import (
+	"edsb/models"
+	"edsb/store"
+)
+
+type likeStore struct {
+	*data
+}
+
+func (s *likeStore) LikePost(userID, postID int) (*models.Like, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.livePost(postID); !ok {
+		return nil, false, store.ErrNotFound
+	}
+	return s.add(targetKey{UserID: userID, PostID: postID})
+}
+
+func (s *likeStore) UnlikePost(userID, postID int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.remove(targetKey{UserID: userID, PostID: postID}), nil
+}
+
+func (s *likeStore) LikeComment(userID, commentID int) (*models.Like, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.liveComment(commentID); !ok {
+		return nil, false, store.ErrNotFound
+	}
+	return s.add(targetKey{UserID: userID, CommentID: commentID})
+}
+
+func (s *likeStore) UnlikeComment(userID, commentID int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.remove(targetKey{UserID: userID, CommentID: commentID}), nil
+}
+
+func (s *likeStore) TogglePost(userID, postID int) (bool, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.livePost(postID); !ok {
+		return false, 0, store.ErrNotFound
+	}
+	return s.toggle(targetKey{UserID: userID, PostID: postID})
+}
+
+func (s *likeStore) ToggleComment(userID, commentID int) (bool, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.liveComment(commentID); !ok {
+		return false, 0, store.ErrNotFound
+	}
+	return s.toggle(targetKey{UserID: userID, CommentID: commentID})
+}
+
+func (s *likeStore) CountForPost(postID int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.posts[postID]; !ok {
+		return 0, store.ErrNotFound
+	}
+	return s.counts[targetKey{PostID: postID}], nil
+}
+
+func (s *likeStore) CountForComment(commentID int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.comments[commentID]; !ok {
+		return 0, store.ErrNotFound
+	}
+	return s.counts[targetKey{CommentID: commentID}], nil
+}
+
+func (s *likeStore) Reconcile() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	actual := map[targetKey]int{}
+	for k := range s.likes {
+		actual[k.target()]++
+	}
+
+	var fixed int64
+	check := func(target targetKey) {
+		if s.counts[target] != actual[target] {
+			s.counts[target] = actual[target]
+			fixed++
+		}
+	}
+	for id := range s.posts {
+		check(targetKey{PostID: id})
+	}
+	for id := range s.comments {
+		check(targetKey{CommentID: id})
+	}
+	return fixed, nil
+}
+
+// add grava o like e incrementa o contador; se já existir, devolve o existente
+func (s *likeStore) add(key targetKey) (*models.Like, bool, error) {
+	if _, ok := s.users[key.UserID]; !ok {
+		return nil, false, store.ErrNotFound
+	}
+	if like, ok := s.likes[key]; ok {
+		return &like, false, nil
+	}
+
+	like := models.Like{ID: s.nextID("likes"), UserID: key.UserID, PostID: key.PostID, CommentID: key.CommentID}
+	s.likes[key] = like
+	s.counts[key.target()]++
+	return &like, true, nil
+}
+
+// remove apaga o like, retornando false se ele não existia
+func (s *likeStore) remove(key targetKey) bool {
+	if _, ok := s.likes[key]; !ok {
+		return false
+	}
+	s.removeLike(key)
+	return true
+}
+
+// toggle alterna o like e retorna o novo estado e a contagem do alvo
+func (s *likeStore) toggle(key targetKey) (bool, int, error) {
+	if s.remove(key) {
+		return false, s.counts[key.target()], nil
+	}
+	if _, _, err := s.add(key); err != nil {
+		return false, 0, err
+	}
+	return true, s.counts[key.target()], nil
+}
@@ -0,0 +1,395 @@
+// memory.go
+package memory
+
+import (
+	"edsb/models"
+	"edsb/store"
+	"sort"
+	"sync"
+	"time"
+)
+
+// New cria repositórios em memória, úteis para testes sem Postgres.
+// Todos compartilham os mesmos dados, então as referências entre tabelas
+// (e as remoções em cascata) se comportam como no banco de dados.
+func New() *store.Store {
+	d := &data{
+		seq:       map[string]int{},
+		users:     map[int]models.User{},
+		passwords: map[int]string{},
+		sessions:  map[string]models.Session{},
+		posts:     map[int]models.Post{},
+		comments:  map[int]models.Comment{},
+		likes:     map[targetKey]models.Like{},
+		counts:    map[targetKey]int{},
+		reactions: map[targetKey]models.Reaction{},
+		follows:   map[followKey]models.Follow{},
+		postTags:  map[postTagKey]time.Time{},
+		mentions:  map[targetKey]time.Time{},
+
+		notifications: map[int]models.Notification{},
+		preferences:   map[preferenceKey]bool{},
+		conversations: map[int]models.Conversation{},
+		members:       map[memberKey]member{},
+		messages:      map[int]models.Message{},
+
+		postRevisions:    map[int][]models.Revision{},
+		commentRevisions: map[int][]models.Revision{},
+	}
+	return &store.Store{
+		Users:     &userStore{d},
+		Sessions:  &sessionStore{d},
+		Posts:     &postStore{d},
+		Comments:  &commentStore{d},
+		Likes:     &likeStore{d},
+		Reactions: &reactionStore{d},
+		Follows:   &followStore{d},
+		Feed:      &feedStore{d},
+		Search:    &searchStore{d},
+		Tags:      &tagStore{d},
+		Mentions:  &mentionStore{d},
+
+		Notifications: &notificationStore{d},
+		Conversations: &conversationStore{d},
+	}
+}
+
+// data guarda as "tabelas" em memória protegidas por um único mutex
+type data struct {
+	mu sync.Mutex
+
+	seq       map[string]int // último ID gerado por tabela, como um SERIAL
+	users     map[int]models.User
+	passwords map[int]string
+	sessions  map[string]models.Session
+	posts     map[int]models.Post
+	comments  map[int]models.Comment
+	likes     map[targetKey]models.Like
+	counts    map[targetKey]int // likes_count por post ({PostID}) ou comentário ({CommentID})
+	reactions map[targetKey]models.Reaction
+	follows   map[followKey]models.Follow
+	postTags  map[postTagKey]time.Time // data em que a tag foi aplicada ao post
+	mentions  map[targetKey]time.Time  // usuário mencionado no post ou comentário
+
+	notifications map[int]models.Notification
+	preferences   map[preferenceKey]bool // tipos de notificação ativados ou desativados
+	conversations map[int]models.Conversation
+	members       map[memberKey]member
+	messages      map[int]models.Message
+
+	postRevisions    map[int][]models.Revision // histórico de cada post, da versão 1 em diante
+	commentRevisions map[int][]models.Revision
+}
+
+// postTagKey reproduz a chave primária (post_id, tag_id) de post_tags
+type postTagKey struct {
+	PostID int
+	Tag    string
+}
+
+// followKey reproduz a chave primária (follower_id, followed_id) de follows
+type followKey struct {
+	FollowerID int
+	FollowedID int
+}
+
+// targetKey identifica o alvo de um like, reação ou menção e reproduz as restrições
+// UNIQUE(user_id, post_id) e UNIQUE(user_id, comment_id)
+type targetKey struct {
+	UserID    int
+	PostID    int
+	CommentID int
+}
+
+// nextID gera o próximo ID da tabela informada
+func (d *data) nextID(table string) int {
+	d.seq[table]++
+	return d.seq[table]
+}
+
+// liveUser retorna o usuário se ele existe e não foi removido
+func (d *data) liveUser(id int) (models.User, bool) {
+	user, ok := d.users[id]
+	return user, ok && user.DeletedAt == nil
+}
+
+// livePost retorna o post se ele existe e não foi removido
+func (d *data) livePost(id int) (models.Post, bool) {
+	post, ok := d.posts[id]
+	return post, ok && post.DeletedAt == nil
+}
+
+// liveComment retorna o comentário se ele existe e não foi removido
+func (d *data) liveComment(id int) (models.Comment, bool) {
+	comment, ok := d.comments[id]
+	return comment, ok && comment.DeletedAt == nil
+}
+
+// hasReplies indica se o comentário tem respostas, removidas ou não
+func (d *data) hasReplies(id int) bool {
+	for _, c := range d.comments {
+		if c.ParentID != nil && *c.ParentID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// hasComments indica se o post tem comentários, removidos ou não
+func (d *data) hasComments(id int) bool {
+	for _, c := range d.comments {
+		if c.PostID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteUser remove o usuário e tudo que referencia ele (ON DELETE CASCADE),
+// menos os comentários com respostas e os posts com comentários
+func (d *data) deleteUser(id int) {
+	delete(d.users, id)
+	delete(d.passwords, id)
+	for sid, s := range d.sessions {
+		if s.UserID == id {
+			delete(d.sessions, sid)
+		}
+	}
+	for k := range d.likes {
+		if k.UserID == id {
+			d.removeLike(k)
+		}
+	}
+	// Os comentários sem respostas saem junto; os demais ficam na thread como
+	// removidos e sem autor (ON DELETE SET NULL)
+	for {
+		var leaves []int
+		for cid, c := range d.comments {
+			if c.UserID == id && !d.hasReplies(cid) {
+				leaves = append(leaves, cid)
+			}
+		}
+		if len(leaves) == 0 {
+			break
+		}
+		for _, cid := range leaves {
+			d.deleteComment(cid)
+		}
+	}
+	now := time.Now()
+	for cid, c := range d.comments {
+		if c.UserID == id {
+			c.UserID = 0
+			c.Content = models.RemovedContent
+			if c.DeletedAt == nil {
+				c.DeletedAt = &now
+			}
+			d.comments[cid] = c
+		}
+	}
+	// Os posts sem comentários saem junto; os demais continuam com a
+	// discussão, sem autor e sem o título, o conteúdo, o histórico e as
+	// menções do usuário
+	for pid, p := range d.posts {
+		if p.UserID != id {
+			continue
+		}
+		if !d.hasComments(pid) {
+			d.deletePost(pid)
+			continue
+		}
+		p.UserID = 0
+		p.Title, p.Content = models.RemovedContent, models.RemovedContent
+		p.EditedAt = nil
+		d.posts[pid] = p
+		delete(d.postRevisions, pid)
+		for k := range d.mentions {
+			if k.PostID == pid {
+				delete(d.mentions, k)
+			}
+		}
+	}
+	for k := range d.reactions {
+		if k.UserID == id {
+			delete(d.reactions, k)
+		}
+	}
+	for k := range d.follows {
+		if k.FollowerID == id || k.FollowedID == id {
+			delete(d.follows, k)
+		}
+	}
+	for k := range d.mentions {
+		if k.UserID == id {
+			delete(d.mentions, k)
+		}
+	}
+	for nid, n := range d.notifications {
+		if n.UserID == id || n.ActorID == id {
+			delete(d.notifications, nid)
+		}
+	}
+	for k := range d.preferences {
+		if k.UserID == id {
+			delete(d.preferences, k)
+		}
+	}
+	for k := range d.members {
+		if k.UserID == id {
+			delete(d.members, k)
+		}
+	}
+	for mid, msg := range d.messages {
+		if msg.UserID == id {
+			delete(d.messages, mid)
+		}
+	}
+	for cid, c := range d.conversations {
+		if c.CreatedBy == id {
+			c.CreatedBy = 0 // ON DELETE SET NULL
+			d.conversations[cid] = c
+		}
+	}
+	for _, histories := range []map[int][]models.Revision{d.postRevisions, d.commentRevisions} {
+		for _, history := range histories {
+			for i := range history {
+				if history[i].EditorID == id {
+					history[i].EditorID = 0 // ON DELETE SET NULL
+				}
+			}
+		}
+	}
+}
+
+// deletePost remove o post, seus comentários, likes, reações, menções,
+// notificações e histórico
+func (d *data) deletePost(id int) {
+	delete(d.posts, id)
+	delete(d.postRevisions, id)
+	delete(d.counts, targetKey{PostID: id})
+	for cid, c := range d.comments {
+		if c.PostID == id {
+			d.deleteComment(cid)
+		}
+	}
+	for k := range d.likes {
+		if k.PostID == id {
+			delete(d.likes, k)
+		}
+	}
+	for k := range d.reactions {
+		if k.PostID == id {
+			delete(d.reactions, k)
+		}
+	}
+	for k := range d.postTags {
+		if k.PostID == id {
+			delete(d.postTags, k)
+		}
+	}
+	for k := range d.mentions {
+		if k.PostID == id {
+			delete(d.mentions, k)
+		}
+	}
+	for nid, n := range d.notifications {
+		if n.PostID == id {
+			delete(d.notifications, nid)
+		}
+	}
+}
+
+// deleteComment remove o comentário, suas respostas, likes, reações,
+// menções, notificações e histórico
+func (d *data) deleteComment(id int) {
+	delete(d.comments, id)
+	delete(d.commentRevisions, id)
+	for cid, c := range d.comments {
+		if c.ParentID != nil && *c.ParentID == id {
+			d.deleteComment(cid)
+		}
+	}
+	delete(d.counts, targetKey{CommentID: id})
+	for k := range d.likes {
+		if k.CommentID == id {
+			delete(d.likes, k)
+		}
+	}
+	for k := range d.reactions {
+		if k.CommentID == id {
+			delete(d.reactions, k)
+		}
+	}
+	for k := range d.mentions {
+		if k.CommentID == id {
+			delete(d.mentions, k)
+		}
+	}
+	for nid, n := range d.notifications {
+		if n.CommentID == id {
+			delete(d.notifications, nid)
+		}
+	}
+}
+
+// removeLike apaga o like e decrementa o contador do alvo
+func (d *data) removeLike(key targetKey) {
+	if _, ok := d.likes[key]; !ok {
+		return
+	}
+	delete(d.likes, key)
+	if target := key.target(); d.counts[target] > 0 {
+		d.counts[target]--
+	}
+}
+
+// target retorna a chave do alvo do like ou reação (sem o usuário)
+func (k targetKey) target() targetKey {
+	return targetKey{PostID: k.PostID, CommentID: k.CommentID}
+}
+
+// paginate ordena os itens do mais novo para o mais antigo e recorta a página
+// pedida, retornando o cursor da próxima página (nil se for a última)
+func paginate[T any](items []T, page store.Page, key func(T) store.Cursor) ([]T, *store.Cursor) {
+	sort.Slice(items, func(i, j int) bool {
+		a, b := key(items[i]), key(items[j])
+		return (&a).Precedes(b.CreatedAt, b.ID)
+	})
+
+	result := make([]T, 0, page.Limit)
+	for _, item := range items {
+		k := key(item)
+		if !page.After.Precedes(k.CreatedAt, k.ID) {
+			continue
+		}
+		if len(result) == page.Limit {
+			last := key(result[len(result)-1])
+			return result, &last
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// paginateByScore é o equivalente de paginate para listagens ordenadas por
+// pontuação (score DESC, id DESC)
+func paginateByScore[T any](items []T, page store.Page, key func(T) store.Cursor) ([]T, *store.Cursor) {
+	sort.Slice(items, func(i, j int) bool {
+		a, b := key(items[i]), key(items[j])
+		return (&a).PrecedesScore(b.Score, b.ID)
+	})
+
+	result := make([]T, 0, page.Limit)
+	for _, item := range items {
+		k := key(item)
+		if !page.After.PrecedesScore(k.Score, k.ID) {
+			continue
+		}
+		if len(result) == page.Limit {
+			last := key(result[len(result)-1])
+			return result, &last
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
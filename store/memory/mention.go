@@ -0,0 +1,60 @@
+// mention.go
+package memory
+
+import (
+	"edsb/store"
+	"time"
+)
+
+type mentionStore struct {
+	*data
+}
+
+func (s *mentionStore) SetForPost(postID int, usernames []string) ([]int, []int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.posts[postID]; !ok {
+		return nil, nil, store.ErrNotFound
+	}
+	added, removed := s.setMentions(targetKey{PostID: postID}, usernames)
+	return added, removed, nil
+}
+
+func (s *mentionStore) SetForComment(commentID int, usernames []string) ([]int, []int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.comments[commentID]; !ok {
+		return nil, nil, store.ErrNotFound
+	}
+	added, removed := s.setMentions(targetKey{CommentID: commentID}, usernames)
+	return added, removed, nil
+}
+
+// setMentions faz os mencionados no alvo serem exatamente os usuários com os
+// usernames informados
+func (d *data) setMentions(target targetKey, usernames []string) (added, removed []int) {
+	keep := map[int]bool{}
+	for _, username := range usernames {
+		for _, user := range d.users {
+			if user.Username == username && user.DeletedAt == nil {
+				keep[user.ID] = true
+			}
+		}
+	}
+	for k := range d.mentions {
+		if k.target() == target && !keep[k.UserID] {
+			delete(d.mentions, k)
+			removed = append(removed, k.UserID)
+		}
+	}
+	for id := range keep {
+		key := targetKey{UserID: id, PostID: target.PostID, CommentID: target.CommentID}
+		if _, ok := d.mentions[key]; !ok {
+			d.mentions[key] = time.Now()
+			added = append(added, id)
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,198 @@
+// notification.go
+package memory
+
+import (
+	"edsb/models"
+	"edsb/store"
+	"sort"
+	"time"
+)
+
+type notificationStore struct {
+	*data
+}
+
+// preferenceKey reproduz a chave primária (user_id, type) de
+// notification_preferences
+type preferenceKey struct {
+	UserID int
+	Type   string
+}
+
+// notificationKey identifica o autor, o tipo e o alvo de uma notificação e
+// reproduz o índice único das notificações não lidas
+type notificationKey struct {
+	UserID    int
+	ActorID   int
+	Type      string
+	PostID    int
+	CommentID int
+}
+
+func keyOf(n models.Notification) notificationKey {
+	return notificationKey{UserID: n.UserID, ActorID: n.ActorID, Type: n.Type, PostID: n.PostID, CommentID: n.CommentID}
+}
+
+// groupKey identifica o grupo de uma notificação em List
+type groupKey struct {
+	Type      string
+	PostID    int
+	CommentID int
+	Read      bool
+}
+
+func (n notificationKey) group(read bool) groupKey {
+	return groupKey{Type: n.Type, PostID: n.PostID, CommentID: n.CommentID, Read: read}
+}
+
+func (s *notificationStore) Create(n *models.Notification) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[n.UserID]; !ok {
+		return false, store.ErrNotFound
+	}
+	if _, ok := s.users[n.ActorID]; !ok {
+		return false, store.ErrNotFound
+	}
+	if enabled, ok := s.preferences[preferenceKey{UserID: n.UserID, Type: n.Type}]; ok && !enabled {
+		return false, nil
+	}
+	key := keyOf(*n)
+	for _, other := range s.notifications {
+		if other.ReadAt == nil && keyOf(other) == key {
+			return false, nil
+		}
+	}
+
+	n.ID = s.nextID("notifications")
+	n.CreatedAt = time.Now()
+	n.ReadAt = nil
+	s.notifications[n.ID] = *n
+	return true, nil
+}
+
+func (s *notificationStore) Remove(n models.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := keyOf(n)
+	for id, other := range s.notifications {
+		if keyOf(other) == key {
+			delete(s.notifications, id)
+		}
+	}
+	return nil
+}
+
+func (s *notificationStore) List(userID int, unreadOnly bool, page store.Page) ([]models.NotificationGroup, *store.Cursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Agrupa do mais novo para o mais antigo, para que os autores de cada
+	// grupo fiquem em ordem
+	var notifications []models.Notification
+	for _, n := range s.notifications {
+		if n.UserID == userID && (!unreadOnly || n.ReadAt == nil) {
+			notifications = append(notifications, n)
+		}
+	}
+	sort.Slice(notifications, func(i, j int) bool {
+		a := store.Cursor{CreatedAt: notifications[i].CreatedAt, ID: notifications[i].ID}
+		return a.Precedes(notifications[j].CreatedAt, notifications[j].ID)
+	})
+
+	index := map[groupKey]int{}
+	var groups []models.NotificationGroup
+	actors := map[groupKey]map[int]bool{}
+	for _, n := range notifications {
+		key := keyOf(n).group(n.ReadAt != nil)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			actors[key] = map[int]bool{}
+			groups = append(groups, models.NotificationGroup{
+				ID: n.ID, Type: n.Type, PostID: n.PostID, CommentID: n.CommentID,
+				Actors: []models.NotificationActor{}, Read: n.ReadAt != nil, CreatedAt: n.CreatedAt,
+			})
+		}
+		if actors[key][n.ActorID] {
+			continue
+		}
+		actors[key][n.ActorID] = true
+		groups[i].ActorsCount++
+		if len(groups[i].Actors) < models.MaxGroupActors {
+			groups[i].Actors = append(groups[i].Actors, models.NotificationActor{ID: n.ActorID, Username: s.users[n.ActorID].Username})
+		}
+	}
+
+	groups, next := paginate(groups, page, func(g models.NotificationGroup) store.Cursor {
+		return store.Cursor{CreatedAt: g.CreatedAt, ID: g.ID}
+	})
+	return groups, next, nil
+}
+
+func (s *notificationStore) MarkRead(userID, id int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	target, ok := s.notifications[id]
+	if !ok || target.UserID != userID {
+		return 0, store.ErrNotFound
+	}
+	group := keyOf(target).group(false)
+	return s.markRead(func(n models.Notification) bool {
+		return n.UserID == userID && keyOf(n).group(false) == group
+	}), nil
+}
+
+func (s *notificationStore) MarkAllRead(userID int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.markRead(func(n models.Notification) bool { return n.UserID == userID }), nil
+}
+
+// markRead marca como lidas as notificações não lidas que satisfazem match
+func (d *data) markRead(match func(models.Notification) bool) int64 {
+	var marked int64
+	now := time.Now()
+	for id, n := range d.notifications {
+		if n.ReadAt == nil && match(n) {
+			n.ReadAt = &now
+			d.notifications[id] = n
+			marked++
+		}
+	}
+	return marked
+}
+
+func (s *notificationStore) Preferences(userID int) (map[string]bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefs := make(map[string]bool, len(models.NotificationTypes))
+	for _, kind := range models.NotificationTypes {
+		prefs[kind] = true
+	}
+	for k, enabled := range s.preferences {
+		if k.UserID == userID {
+			prefs[k.Type] = enabled
+		}
+	}
+	return prefs, nil
+}
+
+func (s *notificationStore) SetPreferences(userID int, prefs map[string]bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return store.ErrNotFound
+	}
+	for kind, enabled := range prefs {
+		s.preferences[preferenceKey{UserID: userID, Type: kind}] = enabled
+	}
+	return nil
+}
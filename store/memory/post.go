@@ -0,0 +1,183 @@
+// post.go
+package memory
+
+import (
+	"edsb/models"
+	"edsb/store"
+	"time"
+)
+
+type postStore struct {
+	*data
+}
+
+func (s *postStore) Create(post *models.Post) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[post.UserID]; !ok {
+		return store.ErrNotFound
+	}
+
+	post.ID = s.nextID("posts")
+	post.CreatedAt = time.Now()
+	s.posts[post.ID] = *post
+	s.setPostTags(post.ID, post.Tags)
+	return nil
+}
+
+func (s *postStore) Get(id int) (*models.Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	post, ok := s.livePost(id)
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	post = s.withCounts(post)
+	return &post, nil
+}
+
+func (s *postStore) List(q store.PostQuery, page store.Page) ([]models.Post, *store.Cursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	posts := make([]models.Post, 0, len(s.posts))
+	for _, post := range s.posts {
+		if post.DeletedAt != nil || post.CreatedAt.Before(q.Since) {
+			continue
+		}
+		posts = append(posts, s.withCounts(post))
+	}
+
+	var next *store.Cursor
+	switch q.Sort {
+	case store.SortHot:
+		posts, next = paginateByScore(posts, page, func(post models.Post) store.Cursor {
+			return store.Cursor{Score: post.HotScore, ID: post.ID}
+		})
+	case store.SortTop:
+		posts, next = paginateByScore(posts, page, func(post models.Post) store.Cursor {
+			return store.Cursor{Score: float64(post.LikesCount + post.CommentsCount), ID: post.ID}
+		})
+	default:
+		posts, next = paginate(posts, page, func(post models.Post) store.Cursor {
+			return store.Cursor{CreatedAt: post.CreatedAt, ID: post.ID}
+		})
+	}
+	return posts, next, nil
+}
+
+func (s *postStore) Update(post *models.Post, editorID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.livePost(post.ID)
+	if !ok {
+		return store.ErrNotFound
+	}
+	if current.Title == post.Title && current.Content == post.Content {
+		return nil
+	}
+
+	now := time.Now()
+	s.postRevisions[post.ID] = addRevision(s.postRevisions[post.ID],
+		models.Revision{EditorID: current.UserID, Title: current.Title, Content: current.Content, CreatedAt: current.CreatedAt},
+		models.Revision{EditorID: editorID, Title: post.Title, Content: post.Content, CreatedAt: now},
+	)
+	current.Title = post.Title
+	current.Content = post.Content
+	current.EditedAt = &now
+	s.posts[post.ID] = current
+	s.setPostTags(post.ID, post.Tags)
+	return nil
+}
+
+func (s *postStore) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	post, ok := s.livePost(id)
+	if !ok {
+		return store.ErrNotFound
+	}
+	now := time.Now()
+	post.DeletedAt = &now
+	s.posts[id] = post
+	return nil
+}
+
+func (s *postStore) Deleted(id int) (*models.Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	post, ok := s.posts[id]
+	if !ok || post.DeletedAt == nil {
+		return nil, store.ErrNotFound
+	}
+	post = s.withCounts(post)
+	return &post, nil
+}
+
+func (s *postStore) Restore(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Posts cujo autor foi apagado de vez não voltam
+	post, ok := s.posts[id]
+	if !ok || post.DeletedAt == nil || post.UserID == 0 {
+		return store.ErrNotFound
+	}
+	post.DeletedAt = nil
+	s.posts[id] = post
+	return nil
+}
+
+func (s *postStore) Purge(deletedBefore time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for id, post := range s.posts {
+		if post.DeletedAt != nil && post.DeletedAt.Before(deletedBefore) {
+			s.deletePost(id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// withCounts preenche os contadores e a pontuação hot do post, que no banco
+// são colunas mantidas a cada like ou comentário, e as suas tags
+func (d *data) withCounts(post models.Post) models.Post {
+	post.Tags = d.tagsOf(post.ID)
+	post.LikesCount = d.counts[targetKey{PostID: post.ID}]
+	post.CommentsCount = 0
+	for _, c := range d.comments {
+		if c.PostID == post.ID && c.DeletedAt == nil {
+			post.CommentsCount++
+		}
+	}
+	post.HotScore = models.HotScore(post.LikesCount, post.CommentsCount, post.CreatedAt)
+	return post
+}
+
+func (s *postStore) Revisions(postID int) ([]models.Revision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.livePost(postID); !ok {
+		return nil, store.ErrNotFound
+	}
+	return newestFirst(s.postRevisions[postID]), nil
+}
+
+func (s *postStore) Revision(postID, version int) (*models.Revision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.livePost(postID); !ok {
+		return nil, store.ErrNotFound
+	}
+	return findRevision(s.postRevisions[postID], version)
+}
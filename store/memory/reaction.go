@@ -0,0 +1,126 @@
+// reaction.go
+package memory
+
+import (
+	"edsb/models"
+	"edsb/store"
+	"sort"
+	"time"
+)
+
+type reactionStore struct {
+	*data
+}
+
+func (s *reactionStore) ReactToPost(userID, postID int, emoji string) (*models.Reaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.livePost(postID); !ok {
+		return nil, store.ErrNotFound
+	}
+	return s.react(targetKey{UserID: userID, PostID: postID}, emoji)
+}
+
+func (s *reactionStore) RemoveFromPost(userID, postID int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.remove(targetKey{UserID: userID, PostID: postID}), nil
+}
+
+func (s *reactionStore) ReactToComment(userID, commentID int, emoji string) (*models.Reaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.liveComment(commentID); !ok {
+		return nil, store.ErrNotFound
+	}
+	return s.react(targetKey{UserID: userID, CommentID: commentID}, emoji)
+}
+
+func (s *reactionStore) RemoveFromComment(userID, commentID int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.remove(targetKey{UserID: userID, CommentID: commentID}), nil
+}
+
+func (s *reactionStore) CountsForPost(postID int) (map[string]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.counts(targetKey{PostID: postID}), nil
+}
+
+func (s *reactionStore) CountsForComment(commentID int) (map[string]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.counts(targetKey{CommentID: commentID}), nil
+}
+
+func (s *reactionStore) ListForPost(postID int, emoji string) ([]models.Reaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.list(targetKey{PostID: postID}, emoji), nil
+}
+
+func (s *reactionStore) ListForComment(commentID int, emoji string) ([]models.Reaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.list(targetKey{CommentID: commentID}, emoji), nil
+}
+
+// react grava a reação, trocando o emoji se o usuário já tinha reagido
+func (s *reactionStore) react(key targetKey, emoji string) (*models.Reaction, error) {
+	if _, ok := s.users[key.UserID]; !ok {
+		return nil, store.ErrNotFound
+	}
+
+	reaction, ok := s.reactions[key]
+	if !ok {
+		reaction = models.Reaction{ID: s.nextID("reactions"), UserID: key.UserID, PostID: key.PostID, CommentID: key.CommentID}
+	}
+	reaction.Emoji = emoji
+	reaction.CreatedAt = time.Now()
+	s.reactions[key] = reaction
+	return &reaction, nil
+}
+
+func (s *reactionStore) remove(key targetKey) bool {
+	if _, ok := s.reactions[key]; !ok {
+		return false
+	}
+	delete(s.reactions, key)
+	return true
+}
+
+func (s *reactionStore) counts(target targetKey) map[string]int {
+	counts := map[string]int{}
+	for k, reaction := range s.reactions {
+		if k.target() == target {
+			counts[reaction.Emoji]++
+		}
+	}
+	return counts
+}
+
+func (s *reactionStore) list(target targetKey, emoji string) []models.Reaction {
+	var reactions []models.Reaction
+	for k, reaction := range s.reactions {
+		if k.target() == target && (emoji == "" || reaction.Emoji == emoji) {
+			reaction.Username = s.users[k.UserID].Username
+			reactions = append(reactions, reaction)
+		}
+	}
+	sort.Slice(reactions, func(i, j int) bool {
+		if !reactions[i].CreatedAt.Equal(reactions[j].CreatedAt) {
+			return reactions[i].CreatedAt.After(reactions[j].CreatedAt)
+		}
+		return reactions[i].ID > reactions[j].ID
+	})
+	return reactions
+}
@@ -0,0 +1,36 @@
+// revision.go
+package memory
+
+import (
+	"edsb/models"
+	"edsb/store"
+)
+
+// addRevision acrescenta a nova versão ao histórico. Na primeira edição a
+// versão original é gravada antes dela, como versão 1.
+func addRevision(history []models.Revision, original, updated models.Revision) []models.Revision {
+	if len(history) == 0 {
+		original.Version = 1
+		history = append(history, original)
+	}
+	updated.Version = len(history) + 1
+	return append(history, updated)
+}
+
+// newestFirst copia o histórico da versão mais nova para a mais antiga
+func newestFirst(history []models.Revision) []models.Revision {
+	revisions := make([]models.Revision, len(history))
+	for i, r := range history {
+		revisions[len(history)-1-i] = r
+	}
+	return revisions
+}
+
+// findRevision retorna a versão do histórico, ou store.ErrNotFound
+func findRevision(history []models.Revision, version int) (*models.Revision, error) {
+	if version < 1 || version > len(history) {
+		return nil, store.ErrNotFound
+	}
+	r := history[version-1]
+	return &r, nil
+}
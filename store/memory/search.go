@@ -0,0 +1,157 @@
+// search.go
+package memory
+
+import (
+	"edsb/models"
+	"edsb/store"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+type searchStore struct {
+	*data
+}
+
+// Search aproxima a busca do Postgres: todos os termos (sem acentos, sem
+// diferenciar maiúsculas) devem aparecer no texto, termos com "-" não podem
+// aparecer e a relevância é o número de ocorrências, com peso dobrado no título
+func (s *searchStore) Search(q store.SearchQuery, page store.Page) ([]models.SearchResult, *store.Cursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var include, exclude []string
+	for _, term := range strings.Fields(q.Text) {
+		term = normalize(strings.Trim(term, `"`))
+		if strings.HasPrefix(term, "-") {
+			if term = strings.TrimPrefix(term, "-"); term != "" {
+				exclude = append(exclude, term)
+			}
+		} else if term != "" && term != "or" {
+			include = append(include, term)
+		}
+	}
+
+	var results []models.SearchResult
+	add := func(r models.SearchResult, title, body string) {
+		if q.AuthorID != 0 && r.UserID != q.AuthorID ||
+			!q.From.IsZero() && r.CreatedAt.Before(q.From) ||
+			!q.To.IsZero() && !r.CreatedAt.Before(q.To) {
+			return
+		}
+		text := normalize(title + " " + body)
+		for _, term := range exclude {
+			if strings.Contains(text, term) {
+				return
+			}
+		}
+		for _, term := range include {
+			n := strings.Count(normalize(title), term)*2 + strings.Count(normalize(body), term)
+			if n == 0 {
+				return
+			}
+			r.Rank += float64(n)
+		}
+		if len(include) == 0 {
+			return
+		}
+		r.Snippet = snippet(body, include)
+		results = append(results, r)
+	}
+
+	if q.Type == "" || q.Type == models.SearchPost {
+		for _, p := range s.posts {
+			if p.DeletedAt != nil {
+				continue
+			}
+			add(models.SearchResult{Type: models.SearchPost, ID: p.ID, PostID: p.ID, UserID: p.UserID, Title: p.Title, CreatedAt: p.CreatedAt}, p.Title, p.Content)
+		}
+	}
+	if q.Type == "" || q.Type == models.SearchComment {
+		for _, c := range s.comments {
+			if _, ok := s.livePost(c.PostID); !ok || c.DeletedAt != nil {
+				continue
+			}
+			add(models.SearchResult{Type: models.SearchComment, ID: c.ID, PostID: c.PostID, UserID: c.UserID, CreatedAt: c.CreatedAt}, "", c.Content)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.ID > b.ID
+	})
+
+	offset := 0
+	if page.After != nil {
+		offset = page.After.Offset
+	}
+	if offset >= len(results) {
+		return []models.SearchResult{}, nil, nil
+	}
+	results = results[offset:]
+	if len(results) <= page.Limit {
+		return results, nil, nil
+	}
+	return results[:page.Limit], &store.Cursor{Offset: offset + page.Limit}, nil
+}
+
+// accents mapeia as letras acentuadas do português para a letra sem acento
+var accents = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ç': 'c', 'ñ': 'n',
+}
+
+// normalize coloca o texto em minúsculas e sem acentos, mantendo uma runa
+// para cada runa original
+func normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		r = unicode.ToLower(r)
+		if plain, ok := accents[r]; ok {
+			return plain
+		}
+		return r
+	}, s)
+}
+
+// snippet escapa o texto e marca as ocorrências dos termos com <mark>
+func snippet(text string, terms []string) string {
+	original := []rune(text)
+	plain := []rune(normalize(text))
+	marked := make([]bool, len(original))
+	for _, term := range terms {
+		t := []rune(term)
+		for i := 0; i+len(t) <= len(plain); i++ {
+			if string(plain[i:i+len(t)]) == term {
+				for j := i; j < i+len(t); j++ {
+					marked[j] = true
+				}
+			}
+		}
+	}
+
+	var b strings.Builder
+	for i, r := range original {
+		if marked[i] && (i == 0 || !marked[i-1]) {
+			b.WriteString("<mark>")
+		}
+		b.WriteString(html.EscapeString(string(r)))
+		if marked[i] && (i == len(original)-1 || !marked[i+1]) {
+			b.WriteString("</mark>")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,76 @@
+// session.go
+package memory
+
+import (
+	"edsb/models"
+	"edsb/store"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type sessionStore struct {
+	*data
+}
+
+func (s *sessionStore) Create(userID int, ttl time.Duration) (*models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return nil, store.ErrNotFound
+	}
+
+	now := time.Now()
+	session := models.Session{ID: uuid.New().String(), UserID: userID, CreatedAt: now, ExpiresAt: now.Add(ttl)}
+	s.sessions[session.ID] = session
+	return &session, nil
+}
+
+func (s *sessionStore) Get(id string) (*models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok || !session.ExpiresAt.After(time.Now()) {
+		return nil, store.ErrNotFound
+	}
+	return &session, nil
+}
+
+func (s *sessionStore) Renew(session *models.Session, ttl, threshold time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.sessions[session.ID]
+	now := time.Now()
+	if !ok || !current.ExpiresAt.Before(now.Add(threshold)) {
+		return false, nil
+	}
+
+	current.ExpiresAt = now.Add(ttl)
+	s.sessions[session.ID] = current
+	session.ExpiresAt = current.ExpiresAt
+	return true, nil
+}
+
+func (s *sessionStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+	return nil
+}
+
+func (s *sessionStore) DeleteExpired(userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, session := range s.sessions {
+		if session.UserID == userID && !session.ExpiresAt.After(now) {
+			delete(s.sessions, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// tag.go
+package memory
+
+import (
+	"edsb/models"
+	"edsb/store"
+	"sort"
+	"time"
+)
+
+type tagStore struct {
+	*data
+}
+
+// setPostTags faz as tags do post serem exatamente tags, mantendo a data das
+// que o post já tinha
+func (d *data) setPostTags(postID int, tags []string) {
+	keep := map[string]bool{}
+	for _, tag := range tags {
+		keep[tag] = true
+		key := postTagKey{PostID: postID, Tag: tag}
+		if _, ok := d.postTags[key]; !ok {
+			d.postTags[key] = time.Now()
+		}
+	}
+	for k := range d.postTags {
+		if k.PostID == postID && !keep[k.Tag] {
+			delete(d.postTags, k)
+		}
+	}
+}
+
+// tagsOf retorna as tags do post em ordem alfabética
+func (d *data) tagsOf(postID int) []string {
+	var tags []string
+	for k := range d.postTags {
+		if k.PostID == postID {
+			tags = append(tags, k.Tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func (s *tagStore) Posts(tag string, page store.Page) ([]models.Post, *store.Cursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var posts []models.Post
+	for k := range s.postTags {
+		if post, ok := s.livePost(k.PostID); ok && k.Tag == tag {
+			posts = append(posts, s.withCounts(post))
+		}
+	}
+
+	posts, next := paginate(posts, page, func(post models.Post) store.Cursor {
+		return store.Cursor{CreatedAt: post.CreatedAt, ID: post.ID}
+	})
+	return posts, next, nil
+}
+
+func (s *tagStore) Trending(window time.Duration, limit int) ([]models.TrendingTag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	counts := map[string]*models.TrendingTag{}
+	for k, appliedAt := range s.postTags {
+		age := now.Sub(appliedAt)
+		if _, ok := s.livePost(k.PostID); !ok || age > 2*window {
+			continue
+		}
+		tag, ok := counts[k.Tag]
+		if !ok {
+			tag = &models.TrendingTag{Name: k.Tag}
+			counts[k.Tag] = tag
+		}
+		if age <= window {
+			tag.Count++
+		} else {
+			tag.PreviousCount++
+		}
+	}
+
+	tags := []models.TrendingTag{}
+	for _, tag := range counts {
+		if tag.Count > 0 {
+			tag.Velocity = models.TagVelocity(tag.Count, tag.PreviousCount, window)
+			tags = append(tags, *tag)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		a, b := tags[i], tags[j]
+		if da, db := a.Count-a.PreviousCount, b.Count-b.PreviousCount; da != db {
+			return da > db
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Name < b.Name
+	})
+	if len(tags) > limit {
+		tags = tags[:limit]
+	}
+	return tags, nil
+}
@@ -0,0 +1,182 @@
+// user.go
+package memory
+
+import (
+	"edsb/models"
+	"edsb/store"
+	"time"
+)
+
+type userStore struct {
+	*data
+}
+
+func (s *userStore) Create(user *models.User, passwordHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.taken(0, user.Username, user.Email) {
+		return store.ErrConflict
+	}
+
+	user.ID = s.nextID("users")
+	user.Role = models.RoleUser
+	user.CreatedAt = time.Now()
+	user.Password = ""
+	s.users[user.ID] = *user
+	s.passwords[user.ID] = passwordHash
+	return nil
+}
+
+func (s *userStore) Get(id int) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.liveUser(id)
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &user, nil
+}
+
+func (s *userStore) GetByUsername(username string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if user.Username == username && user.DeletedAt == nil {
+			return &user, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (s *userStore) List(page store.Page) ([]models.User, *store.Cursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users := make([]models.User, 0, len(s.users))
+	for _, user := range s.users {
+		if user.DeletedAt == nil {
+			users = append(users, user)
+		}
+	}
+
+	users, next := paginate(users, page, func(user models.User) store.Cursor {
+		return store.Cursor{CreatedAt: user.CreatedAt, ID: user.ID}
+	})
+	return users, next, nil
+}
+
+func (s *userStore) Credentials(email string) (int, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if user.Email == email && user.DeletedAt == nil {
+			return user.ID, s.passwords[user.ID], nil
+		}
+	}
+	return 0, "", store.ErrNotFound
+}
+
+func (s *userStore) DeletedCredentials(email string) (int, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if user.Email == email && user.DeletedAt != nil {
+			return user.ID, s.passwords[user.ID], nil
+		}
+	}
+	return 0, "", store.ErrNotFound
+}
+
+func (s *userStore) Update(user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.liveUser(user.ID)
+	if !ok {
+		return store.ErrNotFound
+	}
+	if s.taken(user.ID, user.Username, user.Email) {
+		return store.ErrConflict
+	}
+
+	current.Username = user.Username
+	current.Email = user.Email
+	if user.Role != "" {
+		current.Role = user.Role
+	}
+	s.users[user.ID] = current
+	return nil
+}
+
+func (s *userStore) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.liveUser(id)
+	if !ok {
+		return store.ErrNotFound
+	}
+	now := time.Now()
+	user.DeletedAt = &now
+	s.users[id] = user
+	for sid, session := range s.sessions {
+		if session.UserID == id {
+			delete(s.sessions, sid)
+		}
+	}
+	return nil
+}
+
+func (s *userStore) Deleted(id int) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok || user.DeletedAt == nil {
+		return nil, store.ErrNotFound
+	}
+	return &user, nil
+}
+
+func (s *userStore) Restore(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok || user.DeletedAt == nil {
+		return store.ErrNotFound
+	}
+	user.DeletedAt = nil
+	s.users[id] = user
+	return nil
+}
+
+func (s *userStore) Purge(deletedBefore time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for id, user := range s.users {
+		if user.DeletedAt != nil && user.DeletedAt.Before(deletedBefore) {
+			s.deleteUser(id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// taken indica se username ou email já pertencem a outro usuário, mesmo que
+// removido, como a restrição UNIQUE do banco
+func (s *userStore) taken(exceptID int, username, email string) bool {
+	for _, other := range s.users {
+		if other.ID != exceptID && (other.Username == username || other.Email == email) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+// page.go
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	// DefaultLimit é o tamanho de página usado quando o cliente não informa limit
+	DefaultLimit = 20
+	// MaxLimit é o maior tamanho de página aceito
+	MaxLimit = 100
+)
+
+// ErrInvalidCursor indica um cursor de paginação malformado
+var ErrInvalidCursor = errors.New("cursor inválido")
+
+// Cursor marca o último item entregue numa listagem ordenada do mais novo para
+// o mais antigo (created_at DESC, id DESC) ou, nas listagens por relevância,
+// da maior para a menor pontuação (score DESC, id DESC). Para o cliente ele é
+// opaco.
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	Score     float64   `json:"s,omitempty"`
+	ID        int       `json:"i"`
+
+	// Offset é usado no lugar dos demais campos pelas listagens sem uma chave
+	// de ordenação estável, como a busca por relevância
+	Offset int `json:"o,omitempty"`
+}
+
+// Page descreve qual página de uma listagem deve ser retornada
+type Page struct {
+	Limit int
+	After *Cursor // nil para a primeira página
+}
+
+// Encode serializa o cursor num token opaco seguro para URLs
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor interpreta um token gerado por Cursor.Encode
+func DecodeCursor(token string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil || (c.ID <= 0 && c.Offset <= 0) {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// Precedes indica se o cursor vem antes da posição (createdAt, id) na ordem
+// decrescente, ou seja, se o item pertence às páginas seguintes. Um cursor
+// nil precede todos os itens.
+func (c *Cursor) Precedes(createdAt time.Time, id int) bool {
+	if c == nil {
+		return true
+	}
+	if !createdAt.Equal(c.CreatedAt) {
+		return createdAt.Before(c.CreatedAt)
+	}
+	return id < c.ID
+}
+
+// PrecedesScore é o equivalente de Precedes para listagens ordenadas por
+// pontuação (score DESC, id DESC)
+func (c *Cursor) PrecedesScore(score float64, id int) bool {
+	if c == nil {
+		return true
+	}
+	if score != c.Score {
+		return score < c.Score
+	}
+	return id < c.ID
+}
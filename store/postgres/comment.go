@@ -0,0 +1,242 @@
+// comment.go
+package postgres
+
+import (
+	"database/sql"
+	"edsb/models"
+	"edsb/store"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type commentStore struct {
+	db *sql.DB
+}
+
+// commentColumns são as colunas lidas por scanComment, nessa ordem
+const commentColumns = "id, post_id, user_id, parent_id, depth, content, likes_count, created_at, edited_at, deleted_at"
+
+func scanComment(row scanner) (models.Comment, error) {
+	var comment models.Comment
+	var userID, parentID sql.NullInt64
+	var editedAt, deletedAt sql.NullTime
+	err := row.Scan(&comment.ID, &comment.PostID, &userID, &parentID, &comment.Depth, &comment.Content, &comment.LikesCount, &comment.CreatedAt, &editedAt, &deletedAt)
+	comment.UserID = int(userID.Int64) // NULL quando o autor foi apagado de vez
+	if parentID.Valid {
+		id := int(parentID.Int64)
+		comment.ParentID = &id
+	}
+	if editedAt.Valid {
+		comment.EditedAt = &editedAt.Time
+	}
+	if deletedAt.Valid {
+		comment.DeletedAt = &deletedAt.Time
+	}
+	return comment, err
+}
+
+// scanComments lê todas as linhas de uma consulta por commentColumns,
+// escondendo o conteúdo dos comentários removidos
+func scanComments(rows *sql.Rows, capacity int) ([]models.Comment, error) {
+	defer rows.Close()
+
+	comments := make([]models.Comment, 0, capacity)
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comment.Redact()
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+func commentCursor(comment models.Comment) store.Cursor {
+	return store.Cursor{CreatedAt: comment.CreatedAt, ID: comment.ID}
+}
+
+func (s *commentStore) Create(comment *models.Comment) error {
+	// Posts removidos não aceitam comentários novos; sem linha inserida,
+	// translate devolve ErrNotFound
+	query := `
+	INSERT INTO comments (post_id, user_id, parent_id, depth, content)
+	SELECT $1::int, $2::int, $3::int, $4::int, $5::text
+	WHERE EXISTS (SELECT 1 FROM posts WHERE id = $1 AND deleted_at IS NULL)
+	RETURNING id, created_at`
+	return translate(s.db.QueryRow(query, comment.PostID, comment.UserID, comment.ParentID, comment.Depth, comment.Content).Scan(&comment.ID, &comment.CreatedAt))
+}
+
+func (s *commentStore) Get(id int) (*models.Comment, error) {
+	comment, err := scanComment(s.db.QueryRow("SELECT "+commentColumns+" FROM comments WHERE id = $1 AND deleted_at IS NULL", id))
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &comment, nil
+}
+
+func (s *commentStore) List(page store.Page) ([]models.Comment, *store.Cursor, error) {
+	after, afterID, limit := pageArgs(page)
+	query := `
+	SELECT ` + commentColumns + ` FROM comments
+	WHERE deleted_at IS NULL
+	  AND ($1::timestamp IS NULL OR (created_at, id) < ($1, $2))
+	ORDER BY created_at DESC, id DESC
+	LIMIT $3`
+	rows, err := s.db.Query(query, after, afterID, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	comments, err := scanComments(rows, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	comments, next := nextCursor(comments, page.Limit, commentCursor)
+	return comments, next, nil
+}
+
+func (s *commentStore) ListByPost(postID int, page store.Page) ([]models.Comment, *store.Cursor, error) {
+	after, afterID, limit := pageArgs(page)
+	query := `
+	SELECT ` + commentColumns + ` FROM comments
+	WHERE post_id = $4 AND parent_id IS NULL
+	  AND (deleted_at IS NULL OR EXISTS (SELECT 1 FROM comments r WHERE r.parent_id = comments.id))
+	  AND ($1::timestamp IS NULL OR (created_at, id) < ($1, $2))
+	ORDER BY created_at DESC, id DESC
+	LIMIT $3`
+	rows, err := s.db.Query(query, after, afterID, limit, postID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	comments, err := scanComments(rows, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	comments, next := nextCursor(comments, page.Limit, commentCursor)
+	return comments, next, nil
+}
+
+func (s *commentStore) Replies(rootIDs []int) ([]models.Comment, error) {
+	if len(rootIDs) == 0 {
+		return []models.Comment{}, nil
+	}
+
+	query := `
+	WITH RECURSIVE thread AS (
+		SELECT ` + commentColumns + ` FROM comments WHERE parent_id = ANY($1)
+		UNION ALL
+		SELECT c.id, c.post_id, c.user_id, c.parent_id, c.depth, c.content, c.likes_count, c.created_at, c.edited_at, c.deleted_at
+		FROM comments c JOIN thread t ON c.parent_id = t.id
+	)
+	SELECT ` + commentColumns + ` FROM thread
+	WHERE deleted_at IS NULL OR EXISTS (SELECT 1 FROM comments r WHERE r.parent_id = thread.id)
+	ORDER BY created_at, id`
+	rows, err := s.db.Query(query, pq.Array(rootIDs))
+	if err != nil {
+		return nil, err
+	}
+	return scanComments(rows, 0)
+}
+
+func (s *commentStore) Update(comment *models.Comment, editorID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// O bloqueio da linha serializa as edições e, com ela, a numeração das versões
+	var original models.Revision
+	lock := "SELECT user_id, content, created_at FROM comments WHERE id = $1 AND deleted_at IS NULL FOR UPDATE"
+	if err := tx.QueryRow(lock, comment.ID).Scan(&original.EditorID, &original.Content, &original.CreatedAt); err != nil {
+		return translate(err)
+	}
+	if original.Content == comment.Content {
+		return nil
+	}
+
+	var editedAt time.Time
+	query := "UPDATE comments SET content = $1, edited_at = CURRENT_TIMESTAMP WHERE id = $2 RETURNING edited_at"
+	if err := tx.QueryRow(query, comment.Content, comment.ID).Scan(&editedAt); err != nil {
+		return translate(err)
+	}
+	first := `
+	INSERT INTO comment_revisions (comment_id, version, editor_id, content, created_at)
+	SELECT $1::int, 1, $2::int, $3::text, $4::timestamp
+	WHERE NOT EXISTS (SELECT 1 FROM comment_revisions WHERE comment_id = $1)`
+	if _, err := tx.Exec(first, comment.ID, original.EditorID, original.Content, original.CreatedAt); err != nil {
+		return err
+	}
+	next := `
+	INSERT INTO comment_revisions (comment_id, version, editor_id, content, created_at)
+	SELECT $1::int, MAX(version) + 1, $2::int, $3::text, $4::timestamp FROM comment_revisions WHERE comment_id = $1`
+	if _, err := tx.Exec(next, comment.ID, nullID(editorID), comment.Content, editedAt); err != nil {
+		return translate(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	comment.EditedAt = &editedAt
+	return nil
+}
+
+func (s *commentStore) Delete(id int) error {
+	return mustAffect(s.db.Exec("UPDATE comments SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL", id))
+}
+
+func (s *commentStore) Deleted(id int) (*models.Comment, error) {
+	comment, err := scanComment(s.db.QueryRow("SELECT "+commentColumns+" FROM comments WHERE id = $1 AND deleted_at IS NOT NULL", id))
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &comment, nil
+}
+
+func (s *commentStore) Restore(id int) error {
+	// Comentários cujo autor foi apagado de vez não voltam
+	query := "UPDATE comments SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL AND user_id IS NOT NULL"
+	return mustAffect(s.db.Exec(query, id))
+}
+
+func (s *commentStore) Purge(deletedBefore time.Time) (int64, error) {
+	// Apagar um comentário apaga suas respostas (ON DELETE CASCADE), então só
+	// saem os que não têm respostas. A cada passada os removidos cujas
+	// respostas também eram removidas passam a não ter mais nenhuma.
+	query := `
+	DELETE FROM comments c
+	WHERE c.deleted_at < $1
+	  AND NOT EXISTS (SELECT 1 FROM comments r WHERE r.parent_id = c.id)`
+	var purged int64
+	for {
+		n, err := rowsAffected(s.db.Exec(query, deletedBefore))
+		if err != nil {
+			return purged, err
+		}
+		purged += n
+		if n == 0 {
+			return purged, nil
+		}
+	}
+}
+
+func (s *commentStore) Revisions(commentID int) ([]models.Revision, error) {
+	if err := exists(s.db, "comments", commentID); err != nil {
+		return nil, err
+	}
+	query := `
+	SELECT version, COALESCE(editor_id, 0), '', content, created_at FROM comment_revisions
+	WHERE comment_id = $1
+	ORDER BY version DESC`
+	return scanRevisions(s.db.Query(query, commentID))
+}
+
+func (s *commentStore) Revision(commentID, version int) (*models.Revision, error) {
+	query := `
+	SELECT r.version, COALESCE(r.editor_id, 0), '', r.content, r.created_at
+	FROM comment_revisions r JOIN comments c ON c.id = r.comment_id AND c.deleted_at IS NULL
+	WHERE r.comment_id = $1 AND r.version = $2`
+	return scanRevision(s.db.QueryRow(query, commentID, version))
+}
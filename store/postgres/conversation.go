@@ -0,0 +1,282 @@
+// conversation.go
+package postgres
+
+import (
+	"database/sql"
+	"edsb/models"
+	"edsb/store"
+
+	"github.com/lib/pq"
+)
+
+type conversationStore struct {
+	db *sql.DB
+}
+
+// conversationColumns são as colunas lidas por scanConversation, do ponto de
+// vista do participante m. Devem ser selecionadas de conversations c junto com
+// conversation_members m.
+const conversationColumns = `c.id, c.title, c.is_group, COALESCE(c.created_by, 0), c.created_at, c.last_message_at, m.muted,
+	(SELECT COUNT(*) FROM messages msg
+	 WHERE msg.conversation_id = c.id AND msg.user_id <> m.user_id AND msg.id > COALESCE(m.last_read_message_id, 0))`
+
+func scanConversation(row scanner) (models.Conversation, error) {
+	var c models.Conversation
+	err := row.Scan(&c.ID, &c.Title, &c.IsGroup, &c.CreatedBy, &c.CreatedAt, &c.LastMessageAt, &c.Muted, &c.UnreadCount)
+	return c, err
+}
+
+func (s *conversationStore) Create(conv *models.Conversation, memberIDs []int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO conversations (title, is_group, created_by) VALUES ($1, $2, $3)
+	RETURNING id, created_at, last_message_at`
+	if err := tx.QueryRow(query, conv.Title, conv.IsGroup, conv.CreatedBy).Scan(&conv.ID, &conv.CreatedAt, &conv.LastMessageAt); err != nil {
+		return translate(err)
+	}
+	members := `INSERT INTO conversation_members (conversation_id, user_id) SELECT $1, unnest($2::int[])`
+	if _, err := tx.Exec(members, conv.ID, pq.Array(memberIDs)); err != nil {
+		return translate(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	byConversation, err := s.members([]int{conv.ID})
+	if err != nil {
+		return err
+	}
+	conv.Members = byConversation[conv.ID]
+	return nil
+}
+
+func (s *conversationStore) FindDirect(userID, otherID int) (*models.Conversation, error) {
+	query := `
+	SELECT c.id FROM conversations c
+	JOIN conversation_members a ON a.conversation_id = c.id AND a.user_id = $1 AND a.left_at IS NULL
+	JOIN conversation_members b ON b.conversation_id = c.id AND b.user_id = $2 AND b.left_at IS NULL
+	WHERE NOT c.is_group
+	ORDER BY c.id
+	LIMIT 1`
+	var id int
+	if err := s.db.QueryRow(query, userID, otherID).Scan(&id); err != nil {
+		return nil, translate(err)
+	}
+	return s.Get(id, userID)
+}
+
+func (s *conversationStore) Get(id, userID int) (*models.Conversation, error) {
+	query := `
+	SELECT ` + conversationColumns + `
+	FROM conversations c
+	JOIN conversation_members m ON m.conversation_id = c.id AND m.user_id = $2 AND m.left_at IS NULL
+	WHERE c.id = $1`
+	conv, err := scanConversation(s.db.QueryRow(query, id, userID))
+	if err != nil {
+		return nil, translate(err)
+	}
+
+	byConversation, err := s.members([]int{id})
+	if err != nil {
+		return nil, err
+	}
+	conv.Members = byConversation[id]
+	return &conv, nil
+}
+
+func (s *conversationStore) List(userID int, page store.Page) ([]models.Conversation, *store.Cursor, error) {
+	after, afterID, limit := pageArgs(page)
+	query := `
+	SELECT ` + conversationColumns + `
+	FROM conversations c
+	JOIN conversation_members m ON m.conversation_id = c.id AND m.user_id = $4 AND m.left_at IS NULL
+	WHERE $1::timestamp IS NULL OR (c.last_message_at, c.id) < ($1, $2)
+	ORDER BY c.last_message_at DESC, c.id DESC
+	LIMIT $3`
+	rows, err := s.db.Query(query, after, afterID, limit, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	convs := make([]models.Conversation, 0, limit)
+	for rows.Next() {
+		conv, err := scanConversation(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		convs = append(convs, conv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	convs, next := nextCursor(convs, page.Limit, func(c models.Conversation) store.Cursor {
+		return store.Cursor{CreatedAt: c.LastMessageAt, ID: c.ID}
+	})
+	ids := make([]int, len(convs))
+	for i, c := range convs {
+		ids[i] = c.ID
+	}
+	byConversation, err := s.members(ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range convs {
+		convs[i].Members = byConversation[convs[i].ID]
+	}
+	return convs, next, nil
+}
+
+// members retorna os participantes (inclusive os que saíram) de cada conversa
+func (s *conversationStore) members(ids []int) (map[int][]models.ConversationMember, error) {
+	query := `
+	SELECT cm.conversation_id, cm.user_id, u.username, cm.joined_at,
+	       COALESCE(cm.last_read_message_id, 0), cm.last_read_at, cm.left_at
+	FROM conversation_members cm
+	JOIN users u ON u.id = cm.user_id
+	WHERE cm.conversation_id = ANY($1)
+	ORDER BY cm.joined_at, cm.user_id`
+	rows, err := s.db.Query(query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byConversation := map[int][]models.ConversationMember{}
+	for rows.Next() {
+		var convID int
+		var m models.ConversationMember
+		var readAt, leftAt sql.NullTime
+		if err := rows.Scan(&convID, &m.UserID, &m.Username, &m.JoinedAt, &m.LastReadMessageID, &readAt, &leftAt); err != nil {
+			return nil, err
+		}
+		if readAt.Valid {
+			m.LastReadAt = &readAt.Time
+		}
+		if leftAt.Valid {
+			m.LeftAt = &leftAt.Time
+		}
+		byConversation[convID] = append(byConversation[convID], m)
+	}
+	return byConversation, rows.Err()
+}
+
+func (s *conversationStore) Send(msg *models.Message) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO messages (conversation_id, user_id, content)
+	SELECT $1, $2, $3
+	WHERE EXISTS (
+		SELECT 1 FROM conversation_members
+		WHERE conversation_id = $1 AND user_id = $2 AND left_at IS NULL
+	)
+	RETURNING id, created_at`
+	if err := tx.QueryRow(query, msg.ConversationID, msg.UserID, msg.Content).Scan(&msg.ID, &msg.CreatedAt); err != nil {
+		return translate(err)
+	}
+	if _, err := tx.Exec("UPDATE conversations SET last_message_at = $2 WHERE id = $1", msg.ConversationID, msg.CreatedAt); err != nil {
+		return err
+	}
+	read := `
+	UPDATE conversation_members SET last_read_message_id = $3, last_read_at = $4
+	WHERE conversation_id = $1 AND user_id = $2`
+	if _, err := tx.Exec(read, msg.ConversationID, msg.UserID, msg.ID, msg.CreatedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *conversationStore) Messages(id, userID int, page store.Page) ([]models.Message, *store.Cursor, error) {
+	if err := s.requireMember(id, userID); err != nil {
+		return nil, nil, err
+	}
+
+	after, afterID, limit := pageArgs(page)
+	query := `
+	SELECT id, conversation_id, user_id, content, created_at FROM messages
+	WHERE conversation_id = $4
+	  AND ($1::timestamp IS NULL OR (created_at, id) < ($1, $2))
+	ORDER BY created_at DESC, id DESC
+	LIMIT $3`
+	rows, err := s.db.Query(query, after, afterID, limit, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	messages := make([]models.Message, 0, limit)
+	for rows.Next() {
+		var msg models.Message
+		if err := rows.Scan(&msg.ID, &msg.ConversationID, &msg.UserID, &msg.Content, &msg.CreatedAt); err != nil {
+			return nil, nil, err
+		}
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	messages, next := nextCursor(messages, page.Limit, func(msg models.Message) store.Cursor {
+		return store.Cursor{CreatedAt: msg.CreatedAt, ID: msg.ID}
+	})
+	return messages, next, nil
+}
+
+func (s *conversationStore) MarkRead(id, userID, messageID int) error {
+	if err := s.requireMember(id, userID); err != nil {
+		return err
+	}
+
+	var target sql.NullInt64
+	query := "SELECT MAX(id) FROM messages WHERE conversation_id = $1 AND ($2::int = 0 OR id = $2)"
+	if err := s.db.QueryRow(query, id, messageID).Scan(&target); err != nil {
+		return err
+	}
+	if !target.Valid {
+		if messageID != 0 {
+			return store.ErrNotFound
+		}
+		return nil // conversa sem mensagens
+	}
+
+	update := `
+	UPDATE conversation_members
+	SET last_read_message_id = GREATEST(COALESCE(last_read_message_id, 0), $3), last_read_at = CURRENT_TIMESTAMP
+	WHERE conversation_id = $1 AND user_id = $2 AND left_at IS NULL`
+	return mustAffect(s.db.Exec(update, id, userID, target.Int64))
+}
+
+func (s *conversationStore) SetMuted(id, userID int, muted bool) error {
+	query := "UPDATE conversation_members SET muted = $3 WHERE conversation_id = $1 AND user_id = $2 AND left_at IS NULL"
+	return mustAffect(s.db.Exec(query, id, userID, muted))
+}
+
+func (s *conversationStore) Leave(id, userID int) error {
+	query := "UPDATE conversation_members SET left_at = CURRENT_TIMESTAMP WHERE conversation_id = $1 AND user_id = $2 AND left_at IS NULL"
+	return mustAffect(s.db.Exec(query, id, userID))
+}
+
+// requireMember retorna store.ErrNotFound se o usuário não participa (ou saiu)
+// da conversa
+func (s *conversationStore) requireMember(id, userID int) error {
+	var member bool
+	query := "SELECT EXISTS (SELECT 1 FROM conversation_members WHERE conversation_id = $1 AND user_id = $2 AND left_at IS NULL)"
+	if err := s.db.QueryRow(query, id, userID).Scan(&member); err != nil {
+		return err
+	}
+	if !member {
+		return store.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+// feed.go
+package postgres
+
+import (
+	"database/sql"
+	"edsb/models"
+	"edsb/store"
+)
+
+// feedStore monta a linha do tempo na leitura (fan-out-on-read): os posts do
+// usuário e de quem ele segue são buscados direto em posts, usando o índice
+// (user_id, created_at, id) para cada autor
+type feedStore struct {
+	db *sql.DB
+}
+
+func (s *feedStore) Home(userID int, page store.Page) ([]models.Post, *store.Cursor, error) {
+	after, afterID, limit := pageArgs(page)
+	query := `
+	SELECT ` + postColumns + ` FROM posts
+	WHERE (user_id = $4 OR user_id IN (SELECT followed_id FROM follows WHERE follower_id = $4))
+	  AND deleted_at IS NULL
+	  AND ($1::timestamp IS NULL OR (created_at, id) < ($1, $2))
+	ORDER BY created_at DESC, id DESC
+	LIMIT $3`
+	rows, err := s.db.Query(query, after, afterID, limit, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	posts, err := scanPosts(rows, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	posts, next := nextCursor(posts, page.Limit, postCursor)
+	return posts, next, nil
+}
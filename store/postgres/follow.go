@@ -0,0 +1,86 @@
+// follow.go
+package postgres
+
+import (
+	"database/sql"
+	"edsb/models"
+	"edsb/store"
+)
+
+type followStore struct {
+	db *sql.DB
+}
+
+func (s *followStore) Follow(followerID, followedID int) (*models.Follow, error) {
+	follow := models.Follow{FollowerID: followerID, FollowedID: followedID}
+	query := `
+	INSERT INTO follows (follower_id, followed_id)
+	SELECT $1::int, $2::int WHERE EXISTS (SELECT 1 FROM users WHERE id = $2 AND deleted_at IS NULL)
+	RETURNING created_at`
+	if err := s.db.QueryRow(query, followerID, followedID).Scan(&follow.CreatedAt); err != nil {
+		return nil, translate(err)
+	}
+	return &follow, nil
+}
+
+func (s *followStore) Unfollow(followerID, followedID int) (bool, error) {
+	res, err := s.db.Exec(`DELETE FROM follows WHERE follower_id = $1 AND followed_id = $2`, followerID, followedID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (s *followStore) Followers(userID int, page store.Page) ([]models.Connection, *store.Cursor, error) {
+	return s.connections("followed_id", "follower_id", userID, page)
+}
+
+func (s *followStore) Following(userID int, page store.Page) ([]models.Connection, *store.Cursor, error) {
+	return s.connections("follower_id", "followed_id", userID, page)
+}
+
+func (s *followStore) Counts(userID int) (int, int, error) {
+	var followers, following int
+	query := `
+	SELECT
+		(SELECT COUNT(*) FROM follows f JOIN users u ON u.id = f.follower_id AND u.deleted_at IS NULL WHERE f.followed_id = $1),
+		(SELECT COUNT(*) FROM follows f JOIN users u ON u.id = f.followed_id AND u.deleted_at IS NULL WHERE f.follower_id = $1)`
+	err := s.db.QueryRow(query, userID).Scan(&followers, &following)
+	return followers, following, err
+}
+
+// connections lista os usuários do lado `other` das relações em que userID
+// aparece na coluna `self`, paginando por (follows.created_at, id do usuário)
+func (s *followStore) connections(self, other string, userID int, page store.Page) ([]models.Connection, *store.Cursor, error) {
+	after, afterID, limit := pageArgs(page)
+	query := `
+	SELECT u.id, u.username, u.email, u.role, u.created_at, f.created_at
+	FROM follows f JOIN users u ON u.id = f.` + other + ` AND u.deleted_at IS NULL
+	WHERE f.` + self + ` = $4
+	  AND ($1::timestamp IS NULL OR (f.created_at, u.id) < ($1, $2))
+	ORDER BY f.created_at DESC, u.id DESC
+	LIMIT $3`
+	rows, err := s.db.Query(query, after, afterID, limit, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	connections := make([]models.Connection, 0, limit)
+	for rows.Next() {
+		var c models.Connection
+		if err := rows.Scan(&c.ID, &c.Username, &c.Email, &c.Role, &c.CreatedAt, &c.Since); err != nil {
+			return nil, nil, err
+		}
+		connections = append(connections, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	connections, next := nextCursor(connections, page.Limit, func(c models.Connection) store.Cursor {
+		return store.Cursor{CreatedAt: c.Since, ID: c.ID}
+	})
+	return connections, next, nil
+}
@@ -0,0 +1,203 @@
+// like.go
+package postgres
+
+import (
+	"database/sql"
+	"edsb/models"
+	"fmt"
+)
+
+type likeStore struct {
+	db *sql.DB
+}
+
+// likeTarget descreve onde um like é gravado: a coluna em likes e a tabela
+// cujo likes_count deve acompanhar
+type likeTarget struct {
+	column string
+	table  string
+}
+
+var (
+	postTarget    = likeTarget{column: "post_id", table: "posts"}
+	commentTarget = likeTarget{column: "comment_id", table: "comments"}
+)
+
+func (s *likeStore) LikePost(userID, postID int) (*models.Like, bool, error) {
+	like, created, err := s.like(postTarget, userID, postID)
+	if like != nil {
+		like.PostID = postID
+	}
+	return like, created, err
+}
+
+func (s *likeStore) UnlikePost(userID, postID int) (bool, error) {
+	return s.unlike(postTarget, userID, postID)
+}
+
+func (s *likeStore) LikeComment(userID, commentID int) (*models.Like, bool, error) {
+	like, created, err := s.like(commentTarget, userID, commentID)
+	if like != nil {
+		like.CommentID = commentID
+	}
+	return like, created, err
+}
+
+func (s *likeStore) UnlikeComment(userID, commentID int) (bool, error) {
+	return s.unlike(commentTarget, userID, commentID)
+}
+
+func (s *likeStore) TogglePost(userID, postID int) (bool, int, error) {
+	return s.toggle(postTarget, userID, postID)
+}
+
+func (s *likeStore) ToggleComment(userID, commentID int) (bool, int, error) {
+	return s.toggle(commentTarget, userID, commentID)
+}
+
+func (s *likeStore) CountForPost(postID int) (int, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT likes_count FROM posts WHERE id = $1`, postID).Scan(&count)
+	return count, translate(err)
+}
+
+func (s *likeStore) CountForComment(commentID int) (int, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT likes_count FROM comments WHERE id = $1`, commentID).Scan(&count)
+	return count, translate(err)
+}
+
+func (s *likeStore) Reconcile() (int64, error) {
+	var fixed int64
+	for _, t := range []likeTarget{postTarget, commentTarget} {
+		query := fmt.Sprintf(`
+		UPDATE %[1]s t SET likes_count = c.total
+		FROM (
+			SELECT x.id, COUNT(l.id) AS total
+			FROM %[1]s x LEFT JOIN likes l ON l.%[2]s = x.id
+			GROUP BY x.id
+		) c
+		WHERE t.id = c.id AND t.likes_count IS DISTINCT FROM c.total`, t.table, t.column)
+		res, err := s.db.Exec(query)
+		if err != nil {
+			return fixed, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return fixed, err
+		}
+		fixed += n
+	}
+	return fixed, nil
+}
+
+// like grava o like e incrementa o contador numa única transação. O
+// ON CONFLICT torna a operação idempotente mesmo com requisições concorrentes.
+func (s *likeStore) like(t likeTarget, userID, targetID int) (*models.Like, bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	like := models.Like{UserID: userID}
+	insert := fmt.Sprintf(`
+	INSERT INTO likes (user_id, %[1]s)
+	SELECT $1::int, $2::int WHERE EXISTS (SELECT 1 FROM %[2]s WHERE id = $2 AND deleted_at IS NULL)
+	ON CONFLICT (user_id, %[1]s) DO NOTHING
+	RETURNING id`, t.column, t.table)
+	err = tx.QueryRow(insert, userID, targetID).Scan(&like.ID)
+	if err == sql.ErrNoRows {
+		// Já curtido (ou alvo removido, e então não há like): devolve o like
+		// existente sem mexer no contador
+		existing := fmt.Sprintf(`SELECT id FROM likes WHERE user_id = $1 AND %s = $2`, t.column)
+		if err := tx.QueryRow(existing, userID, targetID).Scan(&like.ID); err != nil {
+			return nil, false, translate(err)
+		}
+		return &like, false, tx.Commit()
+	}
+	if err != nil {
+		return nil, false, translate(err)
+	}
+
+	update := fmt.Sprintf(`UPDATE %s SET likes_count = likes_count + 1 WHERE id = $1`, t.table)
+	if _, err := tx.Exec(update, targetID); err != nil {
+		return nil, false, err
+	}
+	return &like, true, tx.Commit()
+}
+
+// unlike remove o like e decrementa o contador numa única transação
+func (s *likeStore) unlike(t likeTarget, userID, targetID int) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	del := fmt.Sprintf(`DELETE FROM likes WHERE user_id = $1 AND %s = $2`, t.column)
+	res, err := tx.Exec(del, userID, targetID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		return false, tx.Commit() // Não havia like: nada a fazer
+	}
+
+	update := fmt.Sprintf(`UPDATE %s SET likes_count = GREATEST(likes_count - 1, 0) WHERE id = $1`, t.table)
+	if _, err := tx.Exec(update, targetID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// toggle remove o like se ele existir ou o cria caso contrário, ajustando o
+// contador na mesma transação
+func (s *likeStore) toggle(t likeTarget, userID, targetID int) (bool, int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, 0, err
+	}
+	defer tx.Rollback()
+
+	// Trava o alvo para serializar toggles concorrentes do mesmo post/comentário
+	var count int
+	lock := fmt.Sprintf(`SELECT likes_count FROM %s WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, t.table)
+	if err := tx.QueryRow(lock, targetID).Scan(&count); err != nil {
+		return false, 0, translate(err)
+	}
+
+	del := fmt.Sprintf(`DELETE FROM likes WHERE user_id = $1 AND %s = $2`, t.column)
+	res, err := tx.Exec(del, userID, targetID)
+	if err != nil {
+		return false, 0, err
+	}
+	removed, err := res.RowsAffected()
+	if err != nil {
+		return false, 0, err
+	}
+
+	liked := removed == 0
+	if liked {
+		insert := fmt.Sprintf(`INSERT INTO likes (user_id, %s) VALUES ($1, $2)`, t.column)
+		if _, err := tx.Exec(insert, userID, targetID); err != nil {
+			return false, 0, translate(err)
+		}
+	}
+
+	update := fmt.Sprintf(`
+	UPDATE %s SET likes_count = GREATEST(likes_count + $2, 0) WHERE id = $1
+	RETURNING likes_count`, t.table)
+	delta := -1
+	if liked {
+		delta = 1
+	}
+	if err := tx.QueryRow(update, targetID, delta).Scan(&count); err != nil {
+		return false, 0, err
+	}
+	return liked, count, tx.Commit()
+}
@@ -0,0 +1,71 @@
+// mention.go
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type mentionStore struct {
+	db *sql.DB
+}
+
+func (s *mentionStore) SetForPost(postID int, usernames []string) ([]int, []int, error) {
+	return s.set("post_id", postID, usernames)
+}
+
+func (s *mentionStore) SetForComment(commentID int, usernames []string) ([]int, []int, error) {
+	return s.set("comment_id", commentID, usernames)
+}
+
+// set faz os mencionados no alvo (column = post_id ou comment_id) serem
+// exatamente os usuários com os usernames informados
+func (s *mentionStore) set(column string, id int, usernames []string) ([]int, []int, error) {
+	if usernames == nil {
+		usernames = []string{}
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	remove := `
+	DELETE FROM mentions
+	WHERE ` + column + ` = $1
+	  AND user_id NOT IN (SELECT id FROM users WHERE username = ANY($2) AND deleted_at IS NULL)
+	RETURNING user_id`
+	removed, err := collectIDs(tx.Query(remove, id, pq.Array(usernames)))
+	if err != nil {
+		return nil, nil, err
+	}
+	add := `
+	INSERT INTO mentions (user_id, ` + column + `)
+	SELECT id, $1 FROM users WHERE username = ANY($2) AND deleted_at IS NULL
+	ON CONFLICT DO NOTHING
+	RETURNING user_id`
+	added, err := collectIDs(tx.Query(add, id, pq.Array(usernames)))
+	if err != nil {
+		return nil, nil, translate(err)
+	}
+	return added, removed, tx.Commit()
+}
+
+// collectIDs lê uma consulta que retorna uma única coluna de IDs
+func collectIDs(rows *sql.Rows, err error) ([]int, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,212 @@
+// notification.go
+package postgres
+
+import (
+	"database/sql"
+	"edsb/models"
+	"edsb/store"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+type notificationStore struct {
+	db *sql.DB
+}
+
+func (s *notificationStore) Create(n *models.Notification) (bool, error) {
+	query := `
+	INSERT INTO notifications (user_id, actor_id, type, post_id, comment_id)
+	SELECT $1, $2, $3, $4, $5
+	WHERE NOT EXISTS (
+		SELECT 1 FROM notification_preferences
+		WHERE user_id = $1 AND type = $3 AND NOT enabled
+	)
+	ON CONFLICT DO NOTHING
+	RETURNING id, created_at`
+	err := s.db.QueryRow(query, n.UserID, n.ActorID, n.Type, nullID(n.PostID), nullID(n.CommentID)).Scan(&n.ID, &n.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, translate(err)
+	}
+	return true, nil
+}
+
+func (s *notificationStore) Remove(n models.Notification) error {
+	query := `
+	DELETE FROM notifications
+	WHERE user_id = $1 AND actor_id = $2 AND type = $3
+	  AND post_id IS NOT DISTINCT FROM $4 AND comment_id IS NOT DISTINCT FROM $5`
+	_, err := s.db.Exec(query, n.UserID, n.ActorID, n.Type, nullID(n.PostID), nullID(n.CommentID))
+	return err
+}
+
+func (s *notificationStore) List(userID int, unreadOnly bool, page store.Page) ([]models.NotificationGroup, *store.Cursor, error) {
+	after, afterID, limit := pageArgs(page)
+	query := `
+	SELECT MAX(id), type, COALESCE(post_id, 0), COALESCE(comment_id, 0), read_at IS NOT NULL,
+	       MAX(created_at), COUNT(DISTINCT actor_id),
+	       ARRAY_AGG(actor_id ORDER BY created_at DESC, id DESC)
+	FROM notifications
+	WHERE user_id = $4 AND (NOT $5::boolean OR read_at IS NULL)
+	GROUP BY type, post_id, comment_id, read_at IS NOT NULL
+	HAVING $1::timestamp IS NULL OR (MAX(created_at), MAX(id)) < ($1, $2)
+	ORDER BY MAX(created_at) DESC, MAX(id) DESC
+	LIMIT $3`
+	rows, err := s.db.Query(query, after, afterID, limit, userID, unreadOnly)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	groups := make([]models.NotificationGroup, 0, limit)
+	actorIDs := make([][]int64, 0, limit)
+	for rows.Next() {
+		var g models.NotificationGroup
+		var ids []int64
+		if err := rows.Scan(&g.ID, &g.Type, &g.PostID, &g.CommentID, &g.Read, &g.CreatedAt, &g.ActorsCount, pq.Array(&ids)); err != nil {
+			return nil, nil, err
+		}
+		groups = append(groups, g)
+		actorIDs = append(actorIDs, ids)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	groups, next := nextCursor(groups, page.Limit, func(g models.NotificationGroup) store.Cursor {
+		return store.Cursor{CreatedAt: g.CreatedAt, ID: g.ID}
+	})
+	if err := s.fillActors(groups, actorIDs); err != nil {
+		return nil, nil, err
+	}
+	return groups, next, nil
+}
+
+// fillActors preenche os autores mais recentes de cada grupo a partir dos IDs
+// agregados (do mais novo para o mais antigo, podendo repetir)
+func (s *notificationStore) fillActors(groups []models.NotificationGroup, actorIDs [][]int64) error {
+	var all []int64
+	for i := range groups {
+		seen := map[int64]bool{}
+		var recent []int64
+		for _, id := range actorIDs[i] {
+			if !seen[id] && len(recent) < models.MaxGroupActors {
+				seen[id] = true
+				recent = append(recent, id)
+			}
+		}
+		actorIDs[i] = recent
+		all = append(all, recent...)
+	}
+	if len(all) == 0 {
+		return nil
+	}
+
+	rows, err := s.db.Query("SELECT id, username FROM users WHERE id = ANY($1)", pq.Array(all))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	usernames := map[int64]string{}
+	for rows.Next() {
+		var id int64
+		var username string
+		if err := rows.Scan(&id, &username); err != nil {
+			return err
+		}
+		usernames[id] = username
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range groups {
+		groups[i].Actors = make([]models.NotificationActor, 0, len(actorIDs[i]))
+		for _, id := range actorIDs[i] {
+			groups[i].Actors = append(groups[i].Actors, models.NotificationActor{ID: int(id), Username: usernames[id]})
+		}
+	}
+	return nil
+}
+
+func (s *notificationStore) MarkRead(userID, id int) (int64, error) {
+	var exists bool
+	if err := s.db.QueryRow("SELECT EXISTS (SELECT 1 FROM notifications WHERE id = $1 AND user_id = $2)", id, userID).Scan(&exists); err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, store.ErrNotFound
+	}
+
+	query := `
+	UPDATE notifications n SET read_at = CURRENT_TIMESTAMP
+	FROM notifications g
+	WHERE g.id = $1 AND n.user_id = g.user_id AND n.read_at IS NULL
+	  AND n.type = g.type
+	  AND n.post_id IS NOT DISTINCT FROM g.post_id
+	  AND n.comment_id IS NOT DISTINCT FROM g.comment_id`
+	return rowsAffected(s.db.Exec(query, id))
+}
+
+func (s *notificationStore) MarkAllRead(userID int) (int64, error) {
+	query := "UPDATE notifications SET read_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND read_at IS NULL"
+	return rowsAffected(s.db.Exec(query, userID))
+}
+
+func (s *notificationStore) Preferences(userID int) (map[string]bool, error) {
+	prefs := make(map[string]bool, len(models.NotificationTypes))
+	for _, kind := range models.NotificationTypes {
+		prefs[kind] = true
+	}
+
+	rows, err := s.db.Query("SELECT type, enabled FROM notification_preferences WHERE user_id = $1", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kind string
+		var enabled bool
+		if err := rows.Scan(&kind, &enabled); err != nil {
+			return nil, err
+		}
+		prefs[kind] = enabled
+	}
+	return prefs, rows.Err()
+}
+
+func (s *notificationStore) SetPreferences(userID int, prefs map[string]bool) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO notification_preferences (user_id, type, enabled) VALUES ($1, $2, $3)
+	ON CONFLICT (user_id, type) DO UPDATE SET enabled = EXCLUDED.enabled`
+	for kind, enabled := range prefs {
+		if _, err := tx.Exec(query, userID, kind, enabled); err != nil {
+			return translate(err)
+		}
+	}
+	return tx.Commit()
+}
+
+// nullID grava IDs zerados como NULL
+func nullID(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
+
+// rowsAffected retorna quantas linhas o comando alterou
+func rowsAffected(res sql.Result, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,225 @@
+// post.go
+package postgres
+
+import (
+	"database/sql"
+	"edsb/models"
+	"edsb/store"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type postStore struct {
+	db *sql.DB
+}
+
+// postColumns são as colunas lidas por scanPost, nessa ordem. Devem ser
+// selecionadas da tabela posts sem alias.
+const postColumns = `id, user_id, title, content, likes_count, comments_count, hot_score, created_at, edited_at, deleted_at,
+	ARRAY(SELECT t.name FROM post_tags pt JOIN tags t ON t.id = pt.tag_id WHERE pt.post_id = posts.id ORDER BY t.name)`
+
+func scanPost(row scanner) (models.Post, error) {
+	var post models.Post
+	var userID sql.NullInt64
+	var editedAt, deletedAt sql.NullTime
+	err := row.Scan(&post.ID, &userID, &post.Title, &post.Content, &post.LikesCount, &post.CommentsCount, &post.HotScore, &post.CreatedAt, &editedAt, &deletedAt, pq.Array(&post.Tags))
+	post.UserID = int(userID.Int64) // NULL quando o autor foi apagado de vez
+	if editedAt.Valid {
+		post.EditedAt = &editedAt.Time
+	}
+	if deletedAt.Valid {
+		post.DeletedAt = &deletedAt.Time
+	}
+	return post, err
+}
+
+// scanPosts lê todas as linhas de uma consulta por postColumns
+func scanPosts(rows *sql.Rows, capacity int) ([]models.Post, error) {
+	defer rows.Close()
+
+	posts := make([]models.Post, 0, capacity)
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+	return posts, rows.Err()
+}
+
+func postCursor(post models.Post) store.Cursor {
+	return store.Cursor{CreatedAt: post.CreatedAt, ID: post.ID}
+}
+
+func hotCursor(post models.Post) store.Cursor {
+	return store.Cursor{Score: post.HotScore, ID: post.ID}
+}
+
+func topCursor(post models.Post) store.Cursor {
+	return store.Cursor{Score: float64(post.LikesCount + post.CommentsCount), ID: post.ID}
+}
+
+func (s *postStore) Create(post *models.Post) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO posts (user_id, title, content) VALUES ($1, $2, $3) RETURNING id, created_at"
+	if err := tx.QueryRow(query, post.UserID, post.Title, post.Content).Scan(&post.ID, &post.CreatedAt); err != nil {
+		return translate(err)
+	}
+	if err := setPostTags(tx, post.ID, post.Tags); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *postStore) Get(id int) (*models.Post, error) {
+	post, err := scanPost(s.db.QueryRow("SELECT "+postColumns+" FROM posts WHERE id = $1 AND deleted_at IS NULL", id))
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &post, nil
+}
+
+func (s *postStore) List(q store.PostQuery, page store.Page) ([]models.Post, *store.Cursor, error) {
+	since := sql.NullTime{Time: q.Since, Valid: !q.Since.IsZero()}
+	switch q.Sort {
+	case store.SortHot:
+		return s.ranked("hot_score", hotCursor, since, page)
+	case store.SortTop:
+		return s.ranked("likes_count + comments_count", topCursor, since, page)
+	}
+
+	after, afterID, limit := pageArgs(page)
+	query := `
+	SELECT ` + postColumns + ` FROM posts
+	WHERE deleted_at IS NULL
+	  AND ($4::timestamp IS NULL OR created_at >= $4)
+	  AND ($1::timestamp IS NULL OR (created_at, id) < ($1, $2))
+	ORDER BY created_at DESC, id DESC
+	LIMIT $3`
+	rows, err := s.db.Query(query, after, afterID, limit, since)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	posts, err := scanPosts(rows, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	posts, next := nextCursor(posts, page.Limit, postCursor)
+	return posts, next, nil
+}
+
+// ranked lista os posts em ordem decrescente da expressão score (que deve ter
+// um índice correspondente em (score DESC, id DESC))
+func (s *postStore) ranked(score string, cursor func(models.Post) store.Cursor, since sql.NullTime, page store.Page) ([]models.Post, *store.Cursor, error) {
+	after, afterID, limit := scoreArgs(page)
+	query := `
+	SELECT ` + postColumns + ` FROM posts
+	WHERE deleted_at IS NULL
+	  AND ($4::timestamp IS NULL OR created_at >= $4)
+	  AND ($1::float8 IS NULL OR (` + score + `, id) < ($1, $2))
+	ORDER BY ` + score + ` DESC, id DESC
+	LIMIT $3`
+	rows, err := s.db.Query(query, after, afterID, limit, since)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	posts, err := scanPosts(rows, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	posts, next := nextCursor(posts, page.Limit, cursor)
+	return posts, next, nil
+}
+
+func (s *postStore) Update(post *models.Post, editorID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// O bloqueio da linha serializa as edições e, com ela, a numeração das versões
+	var original models.Revision
+	lock := "SELECT COALESCE(user_id, 0), title, content, created_at FROM posts WHERE id = $1 AND deleted_at IS NULL FOR UPDATE"
+	if err := tx.QueryRow(lock, post.ID).Scan(&original.EditorID, &original.Title, &original.Content, &original.CreatedAt); err != nil {
+		return translate(err)
+	}
+	if original.Title == post.Title && original.Content == post.Content {
+		return nil
+	}
+
+	var editedAt time.Time
+	query := "UPDATE posts SET title = $1, content = $2, edited_at = CURRENT_TIMESTAMP WHERE id = $3 RETURNING edited_at"
+	if err := tx.QueryRow(query, post.Title, post.Content, post.ID).Scan(&editedAt); err != nil {
+		return translate(err)
+	}
+	first := `
+	INSERT INTO post_revisions (post_id, version, editor_id, title, content, created_at)
+	SELECT $1::int, 1, $2::int, $3::text, $4::text, $5::timestamp
+	WHERE NOT EXISTS (SELECT 1 FROM post_revisions WHERE post_id = $1)`
+	if _, err := tx.Exec(first, post.ID, nullID(original.EditorID), original.Title, original.Content, original.CreatedAt); err != nil {
+		return err
+	}
+	next := `
+	INSERT INTO post_revisions (post_id, version, editor_id, title, content, created_at)
+	SELECT $1::int, MAX(version) + 1, $2::int, $3::text, $4::text, $5::timestamp FROM post_revisions WHERE post_id = $1`
+	if _, err := tx.Exec(next, post.ID, nullID(editorID), post.Title, post.Content, editedAt); err != nil {
+		return translate(err)
+	}
+	if err := setPostTags(tx, post.ID, post.Tags); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	post.EditedAt = &editedAt
+	return nil
+}
+
+func (s *postStore) Delete(id int) error {
+	return mustAffect(s.db.Exec("UPDATE posts SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL", id))
+}
+
+func (s *postStore) Deleted(id int) (*models.Post, error) {
+	post, err := scanPost(s.db.QueryRow("SELECT "+postColumns+" FROM posts WHERE id = $1 AND deleted_at IS NOT NULL", id))
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &post, nil
+}
+
+func (s *postStore) Restore(id int) error {
+	return mustAffect(s.db.Exec("UPDATE posts SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL AND user_id IS NOT NULL", id))
+}
+
+func (s *postStore) Purge(deletedBefore time.Time) (int64, error) {
+	return rowsAffected(s.db.Exec("DELETE FROM posts WHERE deleted_at < $1", deletedBefore))
+}
+
+func (s *postStore) Revisions(postID int) ([]models.Revision, error) {
+	if err := exists(s.db, "posts", postID); err != nil {
+		return nil, err
+	}
+	query := `
+	SELECT version, COALESCE(editor_id, 0), title, content, created_at FROM post_revisions
+	WHERE post_id = $1
+	ORDER BY version DESC`
+	return scanRevisions(s.db.Query(query, postID))
+}
+
+func (s *postStore) Revision(postID, version int) (*models.Revision, error) {
+	query := `
+	SELECT r.version, COALESCE(r.editor_id, 0), r.title, r.content, r.created_at
+	FROM post_revisions r JOIN posts p ON p.id = r.post_id AND p.deleted_at IS NULL
+	WHERE r.post_id = $1 AND r.version = $2`
+	return scanRevision(s.db.QueryRow(query, postID, version))
+}
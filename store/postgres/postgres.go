@@ -0,0 +1,104 @@
+// postgres.go
+package postgres
+
+import (
+	"database/sql"
+	"edsb/store"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// New cria os repositórios apoiados no banco de dados Postgres
+func New(db *sql.DB) *store.Store {
+	return &store.Store{
+		Users:     &userStore{db: db},
+		Sessions:  &sessionStore{db: db},
+		Posts:     &postStore{db: db},
+		Comments:  &commentStore{db: db},
+		Likes:     &likeStore{db: db},
+		Reactions: &reactionStore{db: db},
+		Follows:   &followStore{db: db},
+		Feed:      &feedStore{db: db},
+		Search:    &searchStore{db: db},
+		Tags:      &tagStore{db: db},
+		Mentions:  &mentionStore{db: db},
+
+		Notifications: &notificationStore{db: db},
+		Conversations: &conversationStore{db: db},
+	}
+}
+
+// scanner é satisfeito por *sql.Row e *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// Códigos de erro do Postgres tratados pelos repositórios
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+// translate converte erros do driver nos erros do pacote store
+func translate(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.ErrNotFound
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case foreignKeyViolation:
+			return store.ErrNotFound
+		case uniqueViolation:
+			return store.ErrConflict
+		}
+	}
+	return err
+}
+
+// mustAffect retorna store.ErrNotFound quando o comando não alterou nenhuma linha
+func mustAffect(res sql.Result, err error) error {
+	if err != nil {
+		return translate(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+// pageArgs converte a página nos argumentos usados pelas consultas paginadas:
+// o cursor (NULL na primeira página) e o limite acrescido de um item, usado
+// para descobrir se existe uma próxima página
+func pageArgs(page store.Page) (after sql.NullTime, afterID int, limit int) {
+	if page.After != nil {
+		after = sql.NullTime{Time: page.After.CreatedAt, Valid: true}
+		afterID = page.After.ID
+	}
+	return after, afterID, page.Limit + 1
+}
+
+// scoreArgs é o equivalente de pageArgs para listagens ordenadas por pontuação
+func scoreArgs(page store.Page) (after sql.NullFloat64, afterID int, limit int) {
+	if page.After != nil {
+		after = sql.NullFloat64{Float64: page.After.Score, Valid: true}
+		afterID = page.After.ID
+	}
+	return after, afterID, page.Limit + 1
+}
+
+// nextCursor corta o item extra buscado por pageArgs e retorna o cursor da
+// próxima página, ou nil se não houver
+func nextCursor[T any](items []T, limit int, key func(T) store.Cursor) ([]T, *store.Cursor) {
+	if len(items) <= limit {
+		return items, nil
+	}
+	items = items[:limit]
+	c := key(items[len(items)-1])
+	return items, &c
+}
@@ -0,0 +1,125 @@
+// reaction.go
+package postgres
+
+import (
+	"database/sql"
+	"edsb/models"
+	"fmt"
+)
+
+type reactionStore struct {
+	db *sql.DB
+}
+
+func (s *reactionStore) ReactToPost(userID, postID int, emoji string) (*models.Reaction, error) {
+	reaction, err := s.react(postTarget, userID, postID, emoji)
+	if reaction != nil {
+		reaction.PostID = postID
+	}
+	return reaction, err
+}
+
+func (s *reactionStore) RemoveFromPost(userID, postID int) (bool, error) {
+	return s.remove("post_id", userID, postID)
+}
+
+func (s *reactionStore) ReactToComment(userID, commentID int, emoji string) (*models.Reaction, error) {
+	reaction, err := s.react(commentTarget, userID, commentID, emoji)
+	if reaction != nil {
+		reaction.CommentID = commentID
+	}
+	return reaction, err
+}
+
+func (s *reactionStore) RemoveFromComment(userID, commentID int) (bool, error) {
+	return s.remove("comment_id", userID, commentID)
+}
+
+func (s *reactionStore) CountsForPost(postID int) (map[string]int, error) {
+	return s.counts("post_id", postID)
+}
+
+func (s *reactionStore) CountsForComment(commentID int) (map[string]int, error) {
+	return s.counts("comment_id", commentID)
+}
+
+func (s *reactionStore) ListForPost(postID int, emoji string) ([]models.Reaction, error) {
+	return s.list("post_id", postID, emoji)
+}
+
+func (s *reactionStore) ListForComment(commentID int, emoji string) ([]models.Reaction, error) {
+	return s.list("comment_id", commentID, emoji)
+}
+
+// react grava a reação, trocando o emoji se o usuário já tinha reagido. O
+// alvo (descrito como nos likes) não pode ter sido removido.
+func (s *reactionStore) react(t likeTarget, userID, targetID int, emoji string) (*models.Reaction, error) {
+	reaction := models.Reaction{UserID: userID, Emoji: emoji}
+	query := fmt.Sprintf(`
+	INSERT INTO reactions (user_id, %[1]s, emoji)
+	SELECT $1::int, $2::int, $3::text WHERE EXISTS (SELECT 1 FROM %[2]s WHERE id = $2 AND deleted_at IS NULL)
+	ON CONFLICT (user_id, %[1]s) DO UPDATE SET emoji = EXCLUDED.emoji, created_at = CURRENT_TIMESTAMP
+	RETURNING id, created_at`, t.column, t.table)
+	if err := s.db.QueryRow(query, userID, targetID, emoji).Scan(&reaction.ID, &reaction.CreatedAt); err != nil {
+		return nil, translate(err)
+	}
+	return &reaction, nil
+}
+
+func (s *reactionStore) remove(column string, userID, targetID int) (bool, error) {
+	query := fmt.Sprintf(`DELETE FROM reactions WHERE user_id = $1 AND %s = $2`, column)
+	res, err := s.db.Exec(query, userID, targetID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (s *reactionStore) counts(column string, targetID int) (map[string]int, error) {
+	query := fmt.Sprintf(`SELECT emoji, COUNT(*) FROM reactions WHERE %s = $1 GROUP BY emoji`, column)
+	rows, err := s.db.Query(query, targetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var emoji string
+		var count int
+		if err := rows.Scan(&emoji, &count); err != nil {
+			return nil, err
+		}
+		counts[emoji] = count
+	}
+	return counts, rows.Err()
+}
+
+func (s *reactionStore) list(column string, targetID int, emoji string) ([]models.Reaction, error) {
+	query := fmt.Sprintf(`
+	SELECT r.id, r.user_id, u.username, r.emoji, r.created_at
+	FROM reactions r JOIN users u ON u.id = r.user_id
+	WHERE r.%s = $1 AND ($2 = '' OR r.emoji = $2)
+	ORDER BY r.created_at DESC, r.id DESC`, column)
+	rows, err := s.db.Query(query, targetID, emoji)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reactions := make([]models.Reaction, 0)
+	for rows.Next() {
+		reaction := models.Reaction{}
+		if err := rows.Scan(&reaction.ID, &reaction.UserID, &reaction.Username, &reaction.Emoji, &reaction.CreatedAt); err != nil {
+			return nil, err
+		}
+		if column == "post_id" {
+			reaction.PostID = targetID
+		} else {
+			reaction.CommentID = targetID
+		}
+		reactions = append(reactions, reaction)
+	}
+	return reactions, rows.Err()
+}
@@ -0,0 +1,48 @@
+// revision.go
+package postgres
+
+import (
+	"database/sql"
+	"edsb/models"
+	"edsb/store"
+)
+
+// scanRevisions lê as versões de uma consulta por version, editor_id, title,
+// content e created_at
+func scanRevisions(rows *sql.Rows, err error) ([]models.Revision, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []models.Revision{}
+	for rows.Next() {
+		r, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *r)
+	}
+	return revisions, rows.Err()
+}
+
+func scanRevision(row scanner) (*models.Revision, error) {
+	var r models.Revision
+	if err := row.Scan(&r.Version, &r.EditorID, &r.Title, &r.Content, &r.CreatedAt); err != nil {
+		return nil, translate(err)
+	}
+	return &r, nil
+}
+
+// exists retorna store.ErrNotFound se não há linha com o id na tabela ou se
+// ela foi removida (deleted_at)
+func exists(db *sql.DB, table string, id int) error {
+	var found bool
+	if err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM "+table+" WHERE id = $1 AND deleted_at IS NULL)", id).Scan(&found); err != nil {
+		return err
+	}
+	if !found {
+		return store.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+// search.go
+package postgres
+
+import (
+	"database/sql"
+	"edsb/models"
+	"edsb/store"
+	"html"
+	"strings"
+)
+
+type searchStore struct {
+	db *sql.DB
+}
+
+// Delimitadores pedidos ao ts_headline para os termos encontrados. São
+// trocados por <mark> depois que o trecho é escapado, para que o HTML escrito
+// pelos usuários nunca chegue ao cliente.
+const (
+	markStart = "⟦"
+	markStop  = "⟧"
+)
+
+const headlineOptions = "StartSel=" + markStart + ", StopSel=" + markStop + ", MaxFragments=2, MaxWords=30, MinWords=10"
+
+func (s *searchStore) Search(q store.SearchQuery, page store.Page) ([]models.SearchResult, *store.Cursor, error) {
+	offset := 0
+	if page.After != nil {
+		offset = page.After.Offset
+	}
+	from := sql.NullTime{Time: q.From, Valid: !q.From.IsZero()}
+	to := sql.NullTime{Time: q.To, Valid: !q.To.IsZero()}
+
+	// Os trechos são gerados só para a página pedida, depois do LIMIT
+	query := `
+	WITH q AS (SELECT websearch_to_tsquery('pt_unaccent', $1) AS query)
+	SELECT r.type, r.id, r.post_id, r.user_id, r.title,
+		ts_headline('pt_unaccent', r.body, (SELECT query FROM q), $8), r.rank, r.created_at
+	FROM (
+		SELECT 'post' AS type, p.id, p.id AS post_id, p.user_id, p.title, p.content AS body,
+			ts_rank(p.search, q.query) AS rank, p.created_at
+		FROM posts p, q
+		WHERE p.search @@ q.query AND $2 IN ('', 'post') AND p.deleted_at IS NULL
+		  AND ($3 = 0 OR p.user_id = $3)
+		  AND ($4::timestamp IS NULL OR p.created_at >= $4)
+		  AND ($5::timestamp IS NULL OR p.created_at < $5)
+		UNION ALL
+		SELECT 'comment', c.id, c.post_id, c.user_id, '', c.content,
+			ts_rank(c.search, q.query), c.created_at
+		FROM comments c, q
+		WHERE c.search @@ q.query AND $2 IN ('', 'comment') AND c.deleted_at IS NULL
+		  AND EXISTS (SELECT 1 FROM posts p WHERE p.id = c.post_id AND p.deleted_at IS NULL)
+		  AND ($3 = 0 OR c.user_id = $3)
+		  AND ($4::timestamp IS NULL OR c.created_at >= $4)
+		  AND ($5::timestamp IS NULL OR c.created_at < $5)
+		ORDER BY rank DESC, created_at DESC, type, id DESC
+		OFFSET $6 LIMIT $7
+	) r
+	ORDER BY r.rank DESC, r.created_at DESC, r.type, r.id DESC`
+	rows, err := s.db.Query(query, q.Text, q.Type, q.AuthorID, from, to, offset, page.Limit+1, headlineOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	results := make([]models.SearchResult, 0, page.Limit+1)
+	for rows.Next() {
+		var r models.SearchResult
+		if err := rows.Scan(&r.Type, &r.ID, &r.PostID, &r.UserID, &r.Title, &r.Snippet, &r.Rank, &r.CreatedAt); err != nil {
+			return nil, nil, err
+		}
+		r.Snippet = highlight(r.Snippet)
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	results, next := nextCursor(results, page.Limit, func(models.SearchResult) store.Cursor {
+		return store.Cursor{Offset: offset + page.Limit}
+	})
+	return results, next, nil
+}
+
+// highlight escapa o trecho e troca os delimitadores do ts_headline por <mark>
+func highlight(snippet string) string {
+	snippet = html.EscapeString(snippet)
+	return strings.NewReplacer(markStart, "<mark>", markStop, "</mark>").Replace(snippet)
+}
@@ -0,0 +1,70 @@
+// session.go
+package postgres
+
+import (
+	"database/sql"
+	"edsb/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type sessionStore struct {
+	db *sql.DB
+}
+
+func (s *sessionStore) Create(userID int, ttl time.Duration) (*models.Session, error) {
+	session := models.Session{ID: uuid.New().String(), UserID: userID}
+
+	query := `
+	INSERT INTO sessions (id, user_id, expires_at)
+	VALUES ($1, $2, CURRENT_TIMESTAMP + $3 * INTERVAL '1 second')
+	RETURNING created_at, expires_at`
+	err := s.db.QueryRow(query, session.ID, session.UserID, int(ttl.Seconds())).Scan(&session.CreatedAt, &session.ExpiresAt)
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &session, nil
+}
+
+func (s *sessionStore) Get(id string) (*models.Session, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, translate(sql.ErrNoRows)
+	}
+
+	var session models.Session
+	query := `SELECT id, user_id, created_at, expires_at FROM sessions WHERE id = $1 AND expires_at > CURRENT_TIMESTAMP`
+	err := s.db.QueryRow(query, id).Scan(&session.ID, &session.UserID, &session.CreatedAt, &session.ExpiresAt)
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &session, nil
+}
+
+func (s *sessionStore) Renew(session *models.Session, ttl, threshold time.Duration) (bool, error) {
+	query := `
+	UPDATE sessions SET expires_at = CURRENT_TIMESTAMP + $2 * INTERVAL '1 second'
+	WHERE id = $1 AND expires_at < CURRENT_TIMESTAMP + $3 * INTERVAL '1 second'
+	RETURNING expires_at`
+	err := s.db.QueryRow(query, session.ID, int(ttl.Seconds()), int(threshold.Seconds())).Scan(&session.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil // Ainda não é hora de renovar
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *sessionStore) Delete(id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil
+	}
+	_, err := s.db.Exec(`DELETE FROM sessions WHERE id = $1`, id)
+	return err
+}
+
+func (s *sessionStore) DeleteExpired(userID int) error {
+	_, err := s.db.Exec(`DELETE FROM sessions WHERE user_id = $1 AND expires_at <= CURRENT_TIMESTAMP`, userID)
+	return err
+}
@@ -0,0 +1,92 @@
+// tag.go
+package postgres
+
+import (
+	"database/sql"
+	"edsb/models"
+	"edsb/store"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type tagStore struct {
+	db *sql.DB
+}
+
+// setPostTags faz as tags do post serem exatamente tags. Tags que o post já
+// tinha mantêm a data em que foram aplicadas, usada no cálculo de tendências.
+func setPostTags(tx *sql.Tx, postID int, tags []string) error {
+	if tags == nil {
+		tags = []string{}
+	}
+	if _, err := tx.Exec(`INSERT INTO tags (name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING`, pq.Array(tags)); err != nil {
+		return err
+	}
+	remove := `
+	DELETE FROM post_tags pt USING tags t
+	WHERE pt.post_id = $1 AND t.id = pt.tag_id AND NOT t.name = ANY($2)`
+	if _, err := tx.Exec(remove, postID, pq.Array(tags)); err != nil {
+		return err
+	}
+	add := `
+	INSERT INTO post_tags (post_id, tag_id)
+	SELECT $1, id FROM tags WHERE name = ANY($2)
+	ON CONFLICT DO NOTHING`
+	_, err := tx.Exec(add, postID, pq.Array(tags))
+	return err
+}
+
+func (s *tagStore) Posts(tag string, page store.Page) ([]models.Post, *store.Cursor, error) {
+	after, afterID, limit := pageArgs(page)
+	query := `
+	SELECT ` + postColumns + ` FROM posts
+	WHERE id IN (SELECT pt.post_id FROM post_tags pt JOIN tags t ON t.id = pt.tag_id WHERE t.name = $4)
+	  AND deleted_at IS NULL
+	  AND ($1::timestamp IS NULL OR (created_at, id) < ($1, $2))
+	ORDER BY created_at DESC, id DESC
+	LIMIT $3`
+	rows, err := s.db.Query(query, after, afterID, limit, tag)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	posts, err := scanPosts(rows, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	posts, next := nextCursor(posts, page.Limit, postCursor)
+	return posts, next, nil
+}
+
+func (s *tagStore) Trending(window time.Duration, limit int) ([]models.TrendingTag, error) {
+	query := `
+	WITH counts AS (
+		SELECT t.name,
+			COUNT(*) FILTER (WHERE pt.created_at >= CURRENT_TIMESTAMP - make_interval(secs => $1)) AS current,
+			COUNT(*) FILTER (WHERE pt.created_at < CURRENT_TIMESTAMP - make_interval(secs => $1)) AS previous
+		FROM post_tags pt JOIN tags t ON t.id = pt.tag_id JOIN posts p ON p.id = pt.post_id AND p.deleted_at IS NULL
+		WHERE pt.created_at >= CURRENT_TIMESTAMP - make_interval(secs => $1 * 2)
+		GROUP BY t.name
+	)
+	SELECT name, current, previous FROM counts
+	WHERE current > 0
+	ORDER BY current - previous DESC, current DESC, name
+	LIMIT $2`
+	rows, err := s.db.Query(query, window.Seconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make([]models.TrendingTag, 0, limit)
+	for rows.Next() {
+		var tag models.TrendingTag
+		if err := rows.Scan(&tag.Name, &tag.Count, &tag.PreviousCount); err != nil {
+			return nil, err
+		}
+		tag.Velocity = models.TagVelocity(tag.Count, tag.PreviousCount, window)
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
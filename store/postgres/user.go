@@ -0,0 +1,209 @@
+// user.go
+package postgres
+
+import (
+	"database/sql"
+	"edsb/models"
+	"edsb/store"
+	"fmt"
+	"time"
+)
+
+type userStore struct {
+	db *sql.DB
+}
+
+func (s *userStore) Create(user *models.User, passwordHash string) error {
+	query := `INSERT INTO users (username, email, password_hash) VALUES ($1, $2, $3) RETURNING id, role, created_at`
+	err := s.db.QueryRow(query, user.Username, user.Email, passwordHash).Scan(&user.ID, &user.Role, &user.CreatedAt)
+	return translate(err)
+}
+
+func (s *userStore) Get(id int) (*models.User, error) {
+	var user models.User
+	query := "SELECT id, username, email, role, created_at FROM users WHERE id = $1 AND deleted_at IS NULL"
+	if err := s.db.QueryRow(query, id).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.CreatedAt); err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (s *userStore) GetByUsername(username string) (*models.User, error) {
+	var user models.User
+	query := "SELECT id, username, email, role, created_at FROM users WHERE username = $1 AND deleted_at IS NULL"
+	if err := s.db.QueryRow(query, username).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.CreatedAt); err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (s *userStore) List(page store.Page) ([]models.User, *store.Cursor, error) {
+	after, afterID, limit := pageArgs(page)
+	query := `
+	SELECT id, username, email, role, created_at FROM users
+	WHERE deleted_at IS NULL
+	  AND ($1::timestamp IS NULL OR (created_at, id) < ($1, $2))
+	ORDER BY created_at DESC, id DESC
+	LIMIT $3`
+	rows, err := s.db.Query(query, after, afterID, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0, limit)
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.CreatedAt); err != nil {
+			return nil, nil, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	users, next := nextCursor(users, page.Limit, func(user models.User) store.Cursor {
+		return store.Cursor{CreatedAt: user.CreatedAt, ID: user.ID}
+	})
+	return users, next, nil
+}
+
+func (s *userStore) Credentials(email string) (int, string, error) {
+	var id int
+	var passwordHash string
+	query := `SELECT id, password_hash FROM users WHERE email = $1 AND deleted_at IS NULL`
+	if err := s.db.QueryRow(query, email).Scan(&id, &passwordHash); err != nil {
+		return 0, "", translate(err)
+	}
+	return id, passwordHash, nil
+}
+
+func (s *userStore) DeletedCredentials(email string) (int, string, error) {
+	var id int
+	var passwordHash string
+	query := `SELECT id, password_hash FROM users WHERE email = $1 AND deleted_at IS NOT NULL`
+	if err := s.db.QueryRow(query, email).Scan(&id, &passwordHash); err != nil {
+		return 0, "", translate(err)
+	}
+	return id, passwordHash, nil
+}
+
+func (s *userStore) Update(user *models.User) error {
+	query := "UPDATE users SET username = $1, email = $2, role = COALESCE(NULLIF($3, ''), role) WHERE id = $4 AND deleted_at IS NULL"
+	return mustAffect(s.db.Exec(query, user.Username, user.Email, user.Role, user.ID))
+}
+
+func (s *userStore) Delete(id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE users SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL"
+	if err := mustAffect(tx.Exec(query, id)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM sessions WHERE user_id = $1", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *userStore) Deleted(id int) (*models.User, error) {
+	var user models.User
+	var deletedAt time.Time
+	query := "SELECT id, username, email, role, created_at, deleted_at FROM users WHERE id = $1 AND deleted_at IS NOT NULL"
+	if err := s.db.QueryRow(query, id).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.CreatedAt, &deletedAt); err != nil {
+		return nil, translate(err)
+	}
+	user.DeletedAt = &deletedAt
+	return &user, nil
+}
+
+func (s *userStore) Restore(id int) error {
+	return mustAffect(s.db.Exec("UPDATE users SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL", id))
+}
+
+func (s *userStore) Purge(deletedBefore time.Time) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Os likes dos usuários apagados saem em cascata; os contadores (e com
+	// eles o hot_score) são acertados antes
+	for _, t := range []likeTarget{postTarget, commentTarget} {
+		uncount := fmt.Sprintf(`
+		UPDATE %[1]s t SET likes_count = GREATEST(t.likes_count - l.total, 0)
+		FROM (
+			SELECT %[2]s AS id, COUNT(*) AS total FROM likes
+			WHERE user_id IN (SELECT id FROM users WHERE deleted_at < $1) AND %[2]s IS NOT NULL
+			GROUP BY %[2]s
+		) l
+		WHERE t.id = l.id`, t.table, t.column)
+		if _, err := tx.Exec(uncount, deletedBefore); err != nil {
+			return 0, err
+		}
+	}
+
+	// Os comentários dos usuários apagados que não têm respostas saem junto.
+	// Como em Comments.Purge, a cada passada os que só tinham respostas do
+	// próprio usuário passam a não ter mais nenhuma.
+	leaves := `
+	DELETE FROM comments c
+	WHERE c.user_id IN (SELECT id FROM users WHERE deleted_at < $1)
+	  AND NOT EXISTS (SELECT 1 FROM comments r WHERE r.parent_id = c.id)`
+	for {
+		n, err := rowsAffected(tx.Exec(leaves, deletedBefore))
+		if err != nil {
+			return 0, err
+		}
+		if n == 0 {
+			break
+		}
+	}
+
+	// Os demais ficam na thread como removidos; apagar o usuário deixa
+	// user_id NULL (ON DELETE SET NULL)
+	anonymize := `
+	UPDATE comments SET content = $2, deleted_at = COALESCE(deleted_at, CURRENT_TIMESTAMP)
+	WHERE user_id IN (SELECT id FROM users WHERE deleted_at < $1)`
+	if _, err := tx.Exec(anonymize, deletedBefore, models.RemovedContent); err != nil {
+		return 0, err
+	}
+
+	// Os posts sem comentários (de outros usuários, já que os do próprio
+	// saíram acima) saem junto. Os demais continuam com a discussão, sem autor
+	// e sem o título, o conteúdo, o histórico e as menções do usuário.
+	posts := "SELECT id FROM posts WHERE user_id IN (SELECT id FROM users WHERE deleted_at < $1)"
+	empty := `
+	DELETE FROM posts p
+	WHERE p.id IN (` + posts + `)
+	  AND NOT EXISTS (SELECT 1 FROM comments c WHERE c.post_id = p.id)`
+	if _, err := tx.Exec(empty, deletedBefore); err != nil {
+		return 0, err
+	}
+	for _, query := range []string{
+		"DELETE FROM post_revisions WHERE post_id IN (" + posts + ")",
+		"DELETE FROM mentions WHERE post_id IN (" + posts + ")",
+	} {
+		if _, err := tx.Exec(query, deletedBefore); err != nil {
+			return 0, err
+		}
+	}
+	anonymize = `
+	UPDATE posts SET title = $2, content = $2, edited_at = NULL
+	WHERE user_id IN (SELECT id FROM users WHERE deleted_at < $1)`
+	if _, err := tx.Exec(anonymize, deletedBefore, models.RemovedContent); err != nil {
+		return 0, err
+	}
+
+	n, err := rowsAffected(tx.Exec("DELETE FROM users WHERE deleted_at < $1", deletedBefore))
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
@@ -0,0 +1,321 @@
+// store.go
+package store
+
+import (
+	"edsb/models"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNotFound indica que o registro (ou algum registro referenciado) não existe
+	ErrNotFound = errors.New("registro não encontrado")
+
+	// ErrConflict indica violação de unicidade (ex.: email já cadastrado)
+	ErrConflict = errors.New("registro já existe")
+)
+
+// Store agrupa os repositórios usados pelos handlers da API. Existe uma
+// implementação em Postgres (store/postgres) e uma em memória (store/memory).
+type Store struct {
+	Users     UserStore
+	Sessions  SessionStore
+	Posts     PostStore
+	Comments  CommentStore
+	Likes     LikeStore
+	Reactions ReactionStore
+	Follows   FollowStore
+	Feed      FeedStore
+	Search    SearchStore
+	Tags      TagStore
+	Mentions  MentionStore
+
+	Notifications NotificationStore
+	Conversations ConversationStore
+}
+
+// UserStore persiste os usuários e suas credenciais
+type UserStore interface {
+	// Create grava o usuário e preenche ID, Role e CreatedAt
+	Create(user *models.User, passwordHash string) error
+	Get(id int) (*models.User, error)
+	GetByUsername(username string) (*models.User, error)
+	// List retorna uma página ordenada do mais novo para o mais antigo e o
+	// cursor da próxima página (nil se esta for a última)
+	List(page Page) ([]models.User, *Cursor, error)
+	// Credentials retorna o ID e o hash da senha do usuário com o email informado
+	Credentials(email string) (id int, passwordHash string, err error)
+	// DeletedCredentials é Credentials para usuários removidos, que podem
+	// restaurar a própria conta ao entrar
+	DeletedCredentials(email string) (id int, passwordHash string, err error)
+	// Update altera username, email e, se não vazio, o papel do usuário
+	Update(user *models.User) error
+	// Delete marca o usuário como removido e encerra suas sessões. Usuários
+	// removidos não são retornados por Get, GetByUsername, List nem
+	// Credentials; seus posts e comentários continuam até Purge.
+	Delete(id int) error
+	// Deleted retorna o usuário removido, ou ErrNotFound se ele não existe ou
+	// não foi removido
+	Deleted(id int) (*models.User, error)
+	// Restore desfaz a remoção do usuário
+	Restore(id int) error
+	// Purge apaga de vez os usuários removidos antes de deletedBefore, com
+	// tudo que referencia eles, e retorna quantos foram apagados. Os
+	// comentários deles que têm respostas ficam na thread, removidos e sem
+	// autor, e os posts com comentários continuam no ar, sem autor e com o
+	// título e o conteúdo trocados por models.RemovedContent. Os contadores de
+	// likes dos alvos curtidos por eles são acertados.
+	Purge(deletedBefore time.Time) (int64, error)
+}
+
+// SessionStore persiste as sessões de login
+type SessionStore interface {
+	Create(userID int, ttl time.Duration) (*models.Session, error)
+	// Get retorna a sessão somente se ela ainda não expirou
+	Get(id string) (*models.Session, error)
+	// Renew estende a validade para ttl caso falte menos que threshold para
+	// expirar, atualizando s.ExpiresAt. Retorna true quando houve renovação.
+	Renew(s *models.Session, ttl, threshold time.Duration) (bool, error)
+	Delete(id string) error
+	DeleteExpired(userID int) error
+}
+
+// PostSort é a ordenação de uma listagem de posts
+type PostSort string
+
+const (
+	// SortNew ordena do mais novo para o mais antigo
+	SortNew PostSort = "new"
+	// SortHot ordena pela pontuação models.HotScore, que pondera engajamento e idade
+	SortHot PostSort = "hot"
+	// SortTop ordena pelo engajamento total (likes + comentários)
+	SortTop PostSort = "top"
+)
+
+// PostQuery define ordenação e filtro de PostStore.List
+type PostQuery struct {
+	Sort  PostSort
+	Since time.Time // somente posts publicados a partir desta data; zero para todos
+}
+
+// PostStore persiste os posts. Os contadores likes_count e comments_count e a
+// pontuação hot são mantidos a cada like ou comentário, nunca na listagem.
+type PostStore interface {
+	// Create grava o post com suas tags (post.Tags) e preenche ID e CreatedAt
+	Create(post *models.Post) error
+	Get(id int) (*models.Post, error)
+	// List retorna uma página na ordem pedida e o cursor da próxima página
+	// (nil se esta for a última)
+	List(query PostQuery, page Page) ([]models.Post, *Cursor, error)
+	// Update altera título, conteúdo e tags do post, marca edited_at e grava
+	// a nova versão no histórico, atribuída a editorID. Se título e conteúdo
+	// não mudaram nada é gravado.
+	Update(post *models.Post, editorID int) error
+	// Delete marca o post como removido. Posts removidos não são retornados
+	// por Get nem pelas listagens.
+	Delete(id int) error
+	// Deleted retorna o post removido, ou ErrNotFound se ele não existe ou não
+	// foi removido
+	Deleted(id int) (*models.Post, error)
+	// Restore desfaz a remoção do post; posts cujo autor foi apagado de vez
+	// não podem ser restaurados
+	Restore(id int) error
+	// Purge apaga de vez os posts removidos antes de deletedBefore, com seus
+	// comentários, e retorna quantos foram apagados
+	Purge(deletedBefore time.Time) (int64, error)
+	// Revisions retorna o histórico do post, da versão mais nova para a mais
+	// antiga; vazio se o post nunca foi editado
+	Revisions(postID int) ([]models.Revision, error)
+	// Revision retorna uma versão do post, ou ErrNotFound
+	Revision(postID, version int) (*models.Revision, error)
+}
+
+// CommentStore persiste os comentários
+type CommentStore interface {
+	// Create grava o comentário (com ParentID e Depth já definidos) e preenche
+	// ID e CreatedAt
+	Create(comment *models.Comment) error
+	Get(id int) (*models.Comment, error)
+	// List retorna uma página ordenada do mais novo para o mais antigo e o
+	// cursor da próxima página (nil se esta for a última)
+	List(page Page) ([]models.Comment, *Cursor, error)
+	// ListByPost retorna uma página dos comentários de primeiro nível do post
+	ListByPost(postID int, page Page) ([]models.Comment, *Cursor, error)
+	// Replies retorna todas as respostas (diretas e indiretas) dos comentários
+	// informados, do mais antigo para o mais novo
+	Replies(rootIDs []int) ([]models.Comment, error)
+	// Update altera o conteúdo do comentário, marca edited_at e grava a nova
+	// versão no histórico, atribuída a editorID. Se o conteúdo não mudou nada
+	// é gravado.
+	Update(comment *models.Comment, editorID int) error
+	// Delete marca o comentário como removido. Get e List deixam de retorná-lo;
+	// ListByPost e Replies continuam retornando-o, com Redact aplicado,
+	// enquanto ele tiver respostas.
+	Delete(id int) error
+	// Deleted retorna o comentário removido, sem Redact, ou ErrNotFound se ele
+	// não existe ou não foi removido
+	Deleted(id int) (*models.Comment, error)
+	// Restore desfaz a remoção do comentário; comentários cujo autor foi
+	// apagado de vez não podem ser restaurados
+	Restore(id int) error
+	// Purge apaga de vez os comentários removidos antes de deletedBefore que
+	// não têm mais respostas, para não levar a thread junto, e retorna quantos
+	// foram apagados
+	Purge(deletedBefore time.Time) (int64, error)
+	// Revisions retorna o histórico do comentário, da versão mais nova para a
+	// mais antiga; vazio se o comentário nunca foi editado
+	Revisions(commentID int) ([]models.Revision, error)
+	// Revision retorna uma versão do comentário, ou ErrNotFound
+	Revision(commentID, version int) (*models.Revision, error)
+}
+
+// LikeStore persiste os likes e mantém os contadores likes_count. Dar e
+// remover likes são operações idempotentes e atualizam o contador na mesma
+// transação que grava o like.
+type LikeStore interface {
+	// LikePost registra o like do usuário no post. Se o like já existia ele é
+	// retornado com created == false e o contador não muda.
+	LikePost(userID, postID int) (like *models.Like, created bool, err error)
+	// UnlikePost remove o like; remover um like inexistente não faz nada
+	UnlikePost(userID, postID int) (removed bool, err error)
+	LikeComment(userID, commentID int) (like *models.Like, created bool, err error)
+	UnlikeComment(userID, commentID int) (removed bool, err error)
+	// TogglePost alterna o like do usuário no post e retorna o novo estado e a
+	// contagem atualizada
+	TogglePost(userID, postID int) (liked bool, count int, err error)
+	ToggleComment(userID, commentID int) (liked bool, count int, err error)
+	CountForPost(postID int) (int, error)
+	CountForComment(commentID int) (int, error)
+	// Reconcile recalcula likes_count de posts e comentários a partir da
+	// tabela likes e retorna quantos registros estavam divergentes
+	Reconcile() (fixed int64, err error)
+}
+
+// ReactionStore persiste as reações (emojis) de usuários a posts e comentários
+type ReactionStore interface {
+	// ReactToPost grava a reação do usuário ao post, substituindo a anterior
+	ReactToPost(userID, postID int, emoji string) (*models.Reaction, error)
+	// RemoveFromPost remove a reação; remover uma reação inexistente não faz nada
+	RemoveFromPost(userID, postID int) (removed bool, err error)
+	ReactToComment(userID, commentID int, emoji string) (*models.Reaction, error)
+	RemoveFromComment(userID, commentID int) (removed bool, err error)
+	// CountsForPost retorna quantas reações de cada emoji o post recebeu
+	CountsForPost(postID int) (map[string]int, error)
+	CountsForComment(commentID int) (map[string]int, error)
+	// ListForPost retorna quem reagiu ao post e com o quê (emoji vazio lista todos)
+	ListForPost(postID int, emoji string) ([]models.Reaction, error)
+	ListForComment(commentID int, emoji string) ([]models.Reaction, error)
+}
+
+// FollowStore persiste o grafo social (quem segue quem)
+type FollowStore interface {
+	// Follow faz follower seguir followed. Retorna ErrConflict se já seguia e
+	// ErrNotFound se algum dos usuários não existe ou se followed foi removido.
+	Follow(followerID, followedID int) (*models.Follow, error)
+	// Unfollow desfaz a relação; desfazer uma relação inexistente não faz nada
+	Unfollow(followerID, followedID int) (removed bool, err error)
+	// Followers retorna uma página de quem segue o usuário, dos mais recentes
+	// para os mais antigos
+	Followers(userID int, page Page) ([]models.Connection, *Cursor, error)
+	// Following retorna uma página de quem o usuário segue
+	Following(userID int, page Page) ([]models.Connection, *Cursor, error)
+	// Counts retorna quantos seguidores o usuário tem e quantos ele segue,
+	// sem contar os usuários removidos, como nas listagens
+	Counts(userID int) (followers, following int, err error)
+}
+
+// FeedStore monta a linha do tempo de cada usuário. A implementação em
+// Postgres calcula a linha do tempo na leitura a partir de follows; ela pode
+// ser trocada por uma tabela pré-computada sem mudar este contrato.
+type FeedStore interface {
+	// Home retorna uma página dos posts do usuário e de quem ele segue, do
+	// mais novo para o mais antigo
+	Home(userID int, page Page) ([]models.Post, *Cursor, error)
+}
+
+// SearchQuery descreve uma busca textual em posts e comentários
+type SearchQuery struct {
+	Text     string
+	Type     string    // models.SearchPost, models.SearchComment ou vazio para ambos
+	AuthorID int       // 0 para qualquer autor
+	From     time.Time // zero para sem limite inferior
+	To       time.Time // exclusivo; zero para sem limite superior
+}
+
+// SearchStore faz a busca textual em posts e comentários
+type SearchStore interface {
+	// Search retorna uma página dos resultados, do mais para o menos relevante
+	Search(query SearchQuery, page Page) ([]models.SearchResult, *Cursor, error)
+}
+
+// TagStore consulta as hashtags dos posts. As tags de cada post são gravadas
+// por PostStore.Create e PostStore.Update.
+type TagStore interface {
+	// Posts retorna uma página dos posts com a tag, do mais novo para o mais antigo
+	Posts(tag string, page Page) ([]models.Post, *Cursor, error)
+	// Trending retorna as tags mais usadas na última janela de tempo,
+	// ordenadas pelo crescimento em relação à janela anterior
+	Trending(window time.Duration, limit int) ([]models.TrendingTag, error)
+}
+
+// MentionStore persiste quem foi mencionado (@username) em cada post e
+// comentário
+type MentionStore interface {
+	// SetForPost faz os mencionados no post serem exatamente os usuários com
+	// os usernames informados (os inexistentes são ignorados). Retorna os IDs
+	// de quem passou a ser e de quem deixou de ser mencionado.
+	SetForPost(postID int, usernames []string) (added, removed []int, err error)
+	SetForComment(commentID int, usernames []string) (added, removed []int, err error)
+}
+
+// NotificationStore persiste as notificações dos usuários e as preferências
+// de quais tipos cada um quer receber
+type NotificationStore interface {
+	// Create grava a notificação e preenche ID e CreatedAt. Não grava nada (e
+	// retorna created == false) se o usuário desativou o tipo ou se já existe
+	// uma notificação igual ainda não lida.
+	Create(n *models.Notification) (created bool, err error)
+	// Remove apaga as notificações, lidas ou não, com o mesmo usuário, autor,
+	// tipo e alvo de n (ex.: ao descurtir ou deixar de seguir)
+	Remove(n models.Notification) error
+	// List retorna uma página das notificações do usuário agrupadas por tipo,
+	// alvo e estado, do grupo mais recente para o mais antigo
+	List(userID int, unreadOnly bool, page Page) ([]models.NotificationGroup, *Cursor, error)
+	// MarkRead marca como lida a notificação e as demais não lidas do seu
+	// grupo. Retorna ErrNotFound se a notificação não é do usuário.
+	MarkRead(userID, id int) (marked int64, err error)
+	MarkAllRead(userID int) (marked int64, err error)
+	// Preferences retorna, para cada tipo de models.NotificationTypes, se o
+	// usuário quer recebê-lo
+	Preferences(userID int) (map[string]bool, error)
+	// SetPreferences altera somente os tipos informados
+	SetPreferences(userID int, prefs map[string]bool) error
+}
+
+// ConversationStore persiste as conversas privadas e suas mensagens. Quem não
+// participa de uma conversa (ou saiu dela) recebe ErrNotFound, como se ela não
+// existisse.
+type ConversationStore interface {
+	// Create grava a conversa com os participantes informados (incluindo quem
+	// a criou) e preenche ID, CreatedAt e Members. Retorna ErrNotFound se algum
+	// usuário não existe.
+	Create(conv *models.Conversation, memberIDs []int) error
+	// FindDirect retorna a conversa direta em que os dois usuários ainda
+	// participam, do ponto de vista de userID
+	FindDirect(userID, otherID int) (*models.Conversation, error)
+	Get(id, userID int) (*models.Conversation, error)
+	// List retorna uma página das conversas do usuário, da que recebeu
+	// mensagem mais recentemente para a mais antiga
+	List(userID int, page Page) ([]models.Conversation, *Cursor, error)
+	// Send grava a mensagem, preenche ID e CreatedAt e a marca como lida pelo
+	// remetente
+	Send(msg *models.Message) error
+	// Messages retorna uma página das mensagens, da mais nova para a mais antiga
+	Messages(id, userID int, page Page) ([]models.Message, *Cursor, error)
+	// MarkRead move o recibo de leitura do usuário até a mensagem informada (0
+	// para a última). O recibo nunca volta para uma mensagem anterior.
+	MarkRead(id, userID, messageID int) error
+	SetMuted(id, userID int, muted bool) error
+	// Leave tira o usuário da conversa; as mensagens dele continuam nela
+	Leave(id, userID int) error
+}
@@ -0,0 +1,9 @@
+// Package templates embute os templates HTML no binário
+package templates
+
+import "embed"
+
+// FS contém base.html, as páginas e os fragmentos em partials/
+//
+//go:embed *.html partials/*.html
+var FS embed.FS
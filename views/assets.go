@@ -0,0 +1,59 @@
+package views
+
+import (
+	"edsb/assets"
+	"fmt"
+)
+
+// vendorFiles são as cópias locais das bibliotecas carregadas das CDNs,
+// baixadas por scripts/vendor-assets.sh
+var vendorFiles = []string{
+	"vendor/bootstrap/bootstrap.min.css",
+	"vendor/bootstrap/bootstrap.bundle.min.js",
+	"vendor/htmx/htmx.min.js",
+	"vendor/htmx/sse.js",
+	"vendor/fontawesome/css/all.min.css",
+}
+
+// Arquivos estáticos referenciados pelas funções asset e vendored
+var (
+	staticAssets *assets.Assets
+	vendored     bool
+)
+
+// UseAssets define os arquivos estáticos usados pelos templates. Com vendor,
+// as páginas usam as cópias locais de Bootstrap, htmx e Font Awesome em vez
+// das CDNs; é um erro se alguma delas não estiver em a.
+func UseAssets(a *assets.Assets, vendor bool) error {
+	if vendor {
+		for _, name := range vendorFiles {
+			if !a.Has(name) {
+				return fmt.Errorf("arquivo %s não encontrado (execute scripts/vendor-assets.sh)", name)
+			}
+		}
+	}
+
+	mu.Lock()
+	staticAssets, vendored = a, vendor
+	mu.Unlock()
+	return nil
+}
+
+// assetURL é a função asset dos templates: a URL versionada do arquivo
+func assetURL(name string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if staticAssets == nil {
+		return assets.Prefix + name
+	}
+	return staticAssets.URL(name)
+}
+
+// useVendored é a função vendored dos templates
+func useVendored() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return vendored
+}
@@ -0,0 +1,136 @@
+package views
+
+import (
+	"edsb/models"
+	"fmt"
+	"html"
+	"html/template"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// funcs são as funções disponíveis em todos os templates
+var funcs = template.FuncMap{
+	"asset":    assetURL,
+	"vendored": useVendored,
+	"relTime":  relTime,
+	"plural":   plural,
+	"markdown": markdown,
+	"likeButton": func(target string, id, count int) LikeButton {
+		return LikeButton{Target: target, ID: id, Count: count}
+	},
+}
+
+// relTime descreve há quanto tempo t ocorreu ("há 5 minutos"). Datas com mais
+// de um mês são mostradas por extenso.
+func relTime(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "agora"
+	case d < time.Hour:
+		return "há " + plural(int(d/time.Minute), "minuto", "minutos")
+	case d < 24*time.Hour:
+		return "há " + plural(int(d/time.Hour), "hora", "horas")
+	case d < 30*24*time.Hour:
+		return "há " + plural(int(d/(24*time.Hour)), "dia", "dias")
+	default:
+		return t.Format("02/01/2006")
+	}
+}
+
+// plural retorna n seguido da forma singular ou plural ("1 comentário",
+// "3 comentários")
+func plural(n int, singular, pluralForm string) string {
+	if n == 1 || n == -1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, pluralForm)
+}
+
+// Marcações aceitas por markdown; as inline são aplicadas sobre o texto já
+// escapado
+var (
+	paragraphPattern = regexp.MustCompile(`\n{2,}`)
+	boldPattern      = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	italicPattern    = regexp.MustCompile(`\*(.+?)\*`)
+	linkPattern      = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^)\s]+)\)`)
+	markerPattern    = regexp.MustCompile("\x00([0-9]+)\x00")
+)
+
+// markdown converte um subconjunto de Markdown (parágrafos, quebras de linha,
+// **negrito**, *itálico*, `código` e [links](https://...)) em HTML, com as
+// menções (@username) apontando para o perfil do usuário. Todo o
+// texto é escapado antes, então HTML escrito pelo usuário nunca é interpretado.
+func markdown(s string) template.HTML {
+	s = strings.ReplaceAll(strings.TrimSpace(s), "\r\n", "\n")
+	if s == "" {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, paragraph := range paragraphPattern.Split(s, -1) {
+		b.WriteString("<p>")
+		for i, line := range strings.Split(paragraph, "\n") {
+			if i > 0 {
+				b.WriteString("<br>")
+			}
+			b.WriteString(inlineMarkdown(line))
+		}
+		b.WriteString("</p>")
+	}
+	return template.HTML(b.String())
+}
+
+// inlineMarkdown formata uma linha; trechos entre crases não são formatados
+func inlineMarkdown(line string) string {
+	parts := strings.Split(line, "`")
+	for i, part := range parts {
+		part = html.EscapeString(part)
+		if i%2 == 1 && i < len(parts)-1 {
+			parts[i] = "<code>" + part + "</code>"
+			continue
+		}
+		part = formatText(part)
+		if i%2 == 1 {
+			part = "`" + part // crase sem par: mantida como texto
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, "")
+}
+
+// formatText aplica links, menções, negrito e itálico a um trecho já escapado.
+// Cada link vira um marcador antes das outras marcações, que assim não
+// alcançam a URL dentro do href (com @ ou * na query), e volta no fim.
+func formatText(s string) string {
+	s = strings.ReplaceAll(s, "\x00", "")
+	var links []string
+	s = linkPattern.ReplaceAllStringFunc(s, func(match string) string {
+		m := linkPattern.FindStringSubmatch(match)
+		links = append(links, `<a href="`+m[2]+`" rel="nofollow noopener" target="_blank">`+emphasis(m[1])+`</a>`)
+		return fmt.Sprintf("\x00%d\x00", len(links)-1)
+	})
+	s = models.MentionPattern.ReplaceAllStringFunc(s, mentionLink)
+	s = emphasis(s)
+	return markerPattern.ReplaceAllStringFunc(s, func(marker string) string {
+		i, _ := strconv.Atoi(markerPattern.FindStringSubmatch(marker)[1])
+		return links[i]
+	})
+}
+
+// emphasis aplica negrito e itálico
+func emphasis(s string) string {
+	s = boldPattern.ReplaceAllString(s, "<strong>$1</strong>")
+	return italicPattern.ReplaceAllString(s, "<em>$1</em>")
+}
+
+// mentionLink troca uma ocorrência de models.MentionPattern pelo link para o
+// perfil do usuário, preservando o caractere que antecede o @
+func mentionLink(match string) string {
+	m := models.MentionPattern.FindStringSubmatch(match)
+	return m[1] + `<a href="/u/` + url.PathEscape(m[2]) + `" class="mention">@` + m[2] + `</a>`
+}
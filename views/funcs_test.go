@@ -0,0 +1,26 @@
+// funcs_test.go
+package views
+
+import "testing"
+
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"oi @bob", `<p>oi <a href="/u/bob" class="mention">@bob</a></p>`},
+		{"**negrito** e *itálico*", `<p><strong>negrito</strong> e <em>itálico</em></p>`},
+		{"<b>`*x*`</b>", `<p>&lt;b&gt;<code>*x*</code>&lt;/b&gt;</p>`},
+		{"[perfil](https://a.com/?u=@bob)",
+			`<p><a href="https://a.com/?u=@bob" rel="nofollow noopener" target="_blank">perfil</a></p>`},
+		{"[busca](https://a.com/*x*?q=**y**) e *z*",
+			`<p><a href="https://a.com/*x*?q=**y**" rel="nofollow noopener" target="_blank">busca</a> e <em>z</em></p>`},
+		{"**veja [o *post*](https://a.com/p) de @bob**",
+			`<p><strong>veja <a href="https://a.com/p" rel="nofollow noopener" target="_blank">o <em>post</em></a> de <a href="/u/bob" class="mention">@bob</a></strong></p>`},
+		{"\x000\x00 [x](https://a.com)", `<p>0 <a href="https://a.com" rel="nofollow noopener" target="_blank">x</a></p>`},
+	}
+	for _, tt := range tests {
+		if got := string(markdown(tt.in)); got != tt.want {
+			t.Errorf("markdown(%q) =\n%s\nesperado\n%s", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package views
+
+import (
+	"bytes"
+	"edsb/models"
+	"edsb/store"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+)
+
+// PostList são os dados do fragmento "post-list"
+type PostList struct {
+	Posts   []models.Post
+	NextURL string // URL da próxima página, vazia na última
+}
+
+// CommentList são os dados do fragmento "comment-list"
+type CommentList struct {
+	Comments []models.Comment
+	NextURL  string // URL da próxima página, vazia na última
+}
+
+// LikeButton são os dados do fragmento "like-button"
+type LikeButton struct {
+	Target string // "posts" ou "comments", como no path da API
+	ID     int
+	Count  int
+	Liked  bool
+}
+
+// IsHTMX indica se a requisição foi feita pelo htmx (cabeçalho HX-Request).
+// Nesse caso os handlers da API respondem com fragmentos HTML em vez de JSON.
+func IsHTMX(r *http.Request) bool {
+	return r.Header.Get("HX-Request") == "true"
+}
+
+// RenderPartial renderiza o fragmento name de templates/partials com o status
+// informado. O HTML é gerado antes de escrever a resposta para que um erro no
+// template resulte num 500 em vez de um fragmento pela metade.
+func RenderPartial(w http.ResponseWriter, status int, name string, data any) {
+	html, err := Partial(name, data)
+	if err != nil {
+		log.Printf("Erro ao renderizar fragmento %s: %v", name, err)
+		http.Error(w, "Erro ao renderizar template", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	io.WriteString(w, html)
+}
+
+// Partial renderiza o fragmento name fora de uma requisição (ex.: para
+// enviá-lo por Server-Sent Events)
+func Partial(name string, data any) (string, error) {
+	mu.RLock()
+	t := partials
+	mu.RUnlock()
+
+	if t == nil {
+		return "", errors.New("templates não carregados")
+	}
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// NextURL monta a URL da próxima página da listagem atual, mantendo os demais
+// parâmetros da requisição. Retorna "" quando não há próxima página.
+func NextURL(r *http.Request, next *store.Cursor) string {
+	if next == nil {
+		return ""
+	}
+	u := *r.URL
+	q := u.Query()
+	q.Set("cursor", next.Encode())
+	u.RawQuery = q.Encode()
+	return u.RequestURI()
+}
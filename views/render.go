@@ -1,23 +1,140 @@
 package views
 
 import (
+	"bytes"
+	"fmt"
 	"html/template"
+	"io/fs"
+	"log"
 	"net/http"
-	"path/filepath"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
-// RenderTemplate carrega e renderiza o template com o layout base
-func RenderTemplate(w http.ResponseWriter, tmpl string, data interface{}) {
-	// Caminhos para os templates
-	basePath := filepath.Join("templates", "base.html")
-	pagePath := filepath.Join("templates", tmpl)
+// Templates parseados por Load, usados em todas as renderizações
+var (
+	mu       sync.RWMutex
+	pages    map[string]*template.Template // por arquivo da página, com base.html e os fragmentos
+	partials *template.Template
+)
 
-	// Parseia o layout base e a página específica
-	t, err := template.ParseFiles(basePath, pagePath)
+// Load parseia todos os templates de fsys (base.html, as páginas na raiz e os
+// fragmentos em partials/) e substitui os usados na renderização. Em caso de
+// erro os templates anteriores continuam valendo.
+func Load(fsys fs.FS) error {
+	p, err := template.New("partials").Funcs(funcs).ParseFS(fsys, "partials/*.html")
 	if err != nil {
-		http.Error(w, "Erro ao carregar template", http.StatusInternalServerError)
+		return fmt.Errorf("fragmentos: %w", err)
+	}
+
+	files, err := fs.Glob(fsys, "*.html")
+	if err != nil {
+		return err
+	}
+	loaded := make(map[string]*template.Template, len(files))
+	for _, file := range files {
+		if file == "base.html" {
+			continue
+		}
+		t, err := p.Clone()
+		if err != nil {
+			return err
+		}
+		if _, err := t.ParseFS(fsys, "base.html", file); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		loaded[file] = t
+	}
+
+	mu.Lock()
+	pages, partials = loaded, p
+	mu.Unlock()
+	return nil
+}
+
+// Watch recarrega os templates de dir sempre que algum arquivo muda,
+// verificando a cada interval. Feito para o modo de desenvolvimento.
+func Watch(dir string, interval time.Duration) {
+	fsys := os.DirFS(dir)
+	last := snapshot(fsys)
+	go func() {
+		for range time.Tick(interval) {
+			current := snapshot(fsys)
+			if current == last {
+				continue
+			}
+			last = current
+			if err := Load(fsys); err != nil {
+				log.Printf("Erro ao recarregar templates: %v", err)
+				continue
+			}
+			log.Println("Templates recarregados.")
+		}
+	}()
+}
+
+// snapshot resume nomes e datas de modificação dos arquivos de fsys, para
+// detectar criações, alterações e remoções
+func snapshot(fsys fs.FS) string {
+	var entries []string
+	fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			entries = append(entries, path+"@"+info.ModTime().String())
+		}
+		return nil
+	})
+	sort.Strings(entries)
+	return strings.Join(entries, "\n")
+}
+
+// RenderTemplate renderiza a página tmpl dentro do layout base
+func RenderTemplate(w http.ResponseWriter, tmpl string, data interface{}) {
+	renderPage(w, http.StatusOK, tmpl, data)
+}
+
+// RenderError responde com a página de erro e o status informado
+func RenderError(w http.ResponseWriter, status int, message string) {
+	mu.RLock()
+	t := pages["error.html"]
+	mu.RUnlock()
+
+	var buf bytes.Buffer
+	if t == nil || t.ExecuteTemplate(&buf, "base.html", map[string]string{"Message": message}) != nil {
+		http.Error(w, message, status)
 		return
 	}
-	// Renderiza o template
-	t.Execute(w, data)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	buf.WriteTo(w)
+}
+
+// renderPage gera o HTML antes de escrever a resposta para que um erro no
+// template resulte na página de erro em vez de uma página pela metade
+func renderPage(w http.ResponseWriter, status int, tmpl string, data any) {
+	mu.RLock()
+	t := pages[tmpl]
+	mu.RUnlock()
+
+	if t == nil {
+		log.Printf("Template %s não encontrado", tmpl)
+		RenderError(w, http.StatusInternalServerError, "Erro ao carregar a página")
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, "base.html", data); err != nil {
+		log.Printf("Erro ao renderizar %s: %v", tmpl, err)
+		RenderError(w, http.StatusInternalServerError, "Erro ao carregar a página")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	buf.WriteTo(w)
 }